@@ -32,7 +32,7 @@ const cgocopy_struct_info* get_UserDetails_metadata(void) {
 CGOCOPY_STRUCT(User,
     CGOCOPY_FIELD(User, id),
     CGOCOPY_FIELD(User, email),
-    CGOCOPY_FIELD(User, details),
+    CGOCOPY_STRUCT_FIELD(User, details, UserDetails),
     CGOCOPY_FIELD(User, account_balance)
 )
 
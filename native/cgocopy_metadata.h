@@ -3,6 +3,7 @@
 
 #include <stddef.h>
 #include <stdbool.h>
+#include <stdio.h>
 #include <string.h>
 
 #ifndef _Alignof
@@ -22,6 +23,7 @@ typedef enum {
 } cgocopy_field_kind;
 
 typedef struct {
+    const char *name;
     size_t offset;
     size_t size;
     const char *type_name;
@@ -44,14 +46,46 @@ typedef struct cgocopy_struct_registry_node {
     struct cgocopy_struct_registry_node *next;
 } cgocopy_struct_registry_node;
 
-void cgocopy_registry_add(cgocopy_struct_registry_node *node);
+// The registry is safe for concurrent use, including registration from
+// constructors of dlopen-loaded libraries while other threads look up.
+//
+// The registry stores nodes, not copies: pointers returned by lookups stay
+// valid only while their node is registered and its info is not freed. Use
+// cgocopy_copy_struct_info when the metadata may be unregistered meanwhile.
+
+// Links node into the registry. A node registered under an existing name
+// shadows the older one until it is removed. Adding a linked node is a no-op.
+// Returns false if node is NULL or the hash table could not be allocated, in
+// which case node is not registered.
+bool cgocopy_registry_add(cgocopy_struct_registry_node *node);
+
+// Unlinks node. Returns false if it was not registered.
+bool cgocopy_registry_remove(cgocopy_struct_registry_node *node);
+
+// Unlinks the newest registration of name, revealing any older one.
+// Returns false if name is not registered.
+bool cgocopy_unregister_struct_info(const char *name);
+
+// Returns the newest registration of name, or NULL. The result is not
+// copied; see the lifetime note above.
 const cgocopy_struct_info *cgocopy_lookup_struct_info(const char *name);
 
+// Returns a copy of the newest registration of name, taken under the
+// registry lock, or NULL if name is not registered or memory ran out. The
+// fields and strings live in the same allocation; release it with free().
+cgocopy_struct_info *cgocopy_copy_struct_info(const char *name);
+
+// Stores up to capacity registered (non-shadowed) structs in out, in no
+// particular order, and returns how many there are in total. Pass NULL and 0
+// to only count them. The structs are not copied; see the lifetime note above.
+size_t cgocopy_registry_list(const cgocopy_struct_info **out, size_t capacity);
+
 #define CGOCOPY_INTERNAL_STRINGIFY(x) CGOCOPY_INTERNAL_STRINGIFY_IMPL(x)
 #define CGOCOPY_INTERNAL_STRINGIFY_IMPL(x) #x
 
 #define CGOCOPY_INTERNAL_FIELD_INIT(kind_value, struct_type, field_name, type_literal, elem_literal, elem_count_value, string_flag) \
     {                                                                                                               \
+        .name = CGOCOPY_INTERNAL_STRINGIFY(field_name),                                                             \
         .offset = offsetof(struct_type, field_name),                                                                \
         .size = sizeof(((struct_type *)0)->field_name),                                                             \
         .type_name = (type_literal),                                                                                \
@@ -78,7 +112,14 @@ const cgocopy_struct_info *cgocopy_lookup_struct_info(const char *name);
     static void cgocopy_register_##struct_type(void) {                                                              \
         cgocopy_registry_node_##struct_type.info = &cgocopy_struct_info_##struct_type;                              \
         cgocopy_registry_node_##struct_type.next = NULL;                                                            \
-        cgocopy_registry_add(&cgocopy_registry_node_##struct_type);                                                 \
+        if (!cgocopy_registry_add(&cgocopy_registry_node_##struct_type)) {                                          \
+            fprintf(stderr, "cgocopy: failed to register %s: out of memory\n",                                     \
+                    CGOCOPY_INTERNAL_STRINGIFY(struct_type));                                                       \
+        }                                                                                                           \
+    }                                                                                                               \
+    static void cgocopy_unregister_##struct_type(void) __attribute__((destructor));                                 \
+    static void cgocopy_unregister_##struct_type(void) {                                                            \
+        cgocopy_registry_remove(&cgocopy_registry_node_##struct_type);                                              \
     }                                                                                                               \
     static inline const cgocopy_struct_info *cgocopy_get_##struct_type##_info(void) {                               \
         return &cgocopy_struct_info_##struct_type;                                                                  \
@@ -1,26 +1,287 @@
 #include "cgocopy_metadata.h"
 
-static cgocopy_struct_registry_node *cgocopy_registry_head = NULL;
+#include <pthread.h>
+#include <stdint.h>
+#include <stdlib.h>
 
-void cgocopy_registry_add(cgocopy_struct_registry_node *node) {
-    // TODO: add synchronization once metadata registration runs concurrently.
-    if (node == NULL) {
+// Registered nodes are chained through node->next in a hash table keyed by
+// struct name. Newer registrations are linked first, so they shadow older
+// ones with the same name until unregistered.
+
+#define CGOCOPY_REGISTRY_INITIAL_BUCKETS 64
+
+static pthread_rwlock_t cgocopy_registry_lock = PTHREAD_RWLOCK_INITIALIZER;
+static cgocopy_struct_registry_node **cgocopy_registry_buckets = NULL;
+static size_t cgocopy_registry_bucket_count = 0;
+static size_t cgocopy_registry_node_count = 0;
+
+static uint64_t cgocopy_registry_hash(const char *name) {
+    // FNV-1a
+    uint64_t hash = 14695981039346656037ULL;
+    for (const unsigned char *p = (const unsigned char *)name; *p != '\0'; p++) {
+        hash ^= *p;
+        hash *= 1099511628211ULL;
+    }
+    return hash;
+}
+
+static const char *cgocopy_registry_node_name(const cgocopy_struct_registry_node *node) {
+    if (node->info == NULL || node->info->name == NULL) {
+        return "";
+    }
+    return node->info->name;
+}
+
+static cgocopy_struct_registry_node **cgocopy_registry_bucket(const char *name) {
+    return &cgocopy_registry_buckets[cgocopy_registry_hash(name) & (cgocopy_registry_bucket_count - 1)];
+}
+
+// Rehashes into twice as many buckets. On allocation failure the table keeps
+// its size and chains just get longer. Caller holds the write lock.
+static void cgocopy_registry_grow(void) {
+    size_t count = cgocopy_registry_bucket_count == 0 ? CGOCOPY_REGISTRY_INITIAL_BUCKETS
+                                                      : cgocopy_registry_bucket_count * 2;
+    cgocopy_struct_registry_node **buckets = calloc(count, sizeof(*buckets));
+    if (buckets == NULL) {
         return;
     }
-    node->next = cgocopy_registry_head;
-    cgocopy_registry_head = node;
+
+    // Walk each old chain from its tail so relinking at the head keeps the
+    // newest-first order within every new chain
+    for (size_t i = 0; i < cgocopy_registry_bucket_count; i++) {
+        cgocopy_struct_registry_node *reversed = NULL;
+        cgocopy_struct_registry_node *cursor = cgocopy_registry_buckets[i];
+        while (cursor != NULL) {
+            cgocopy_struct_registry_node *next = cursor->next;
+            cursor->next = reversed;
+            reversed = cursor;
+            cursor = next;
+        }
+        while (reversed != NULL) {
+            cgocopy_struct_registry_node *next = reversed->next;
+            size_t index = cgocopy_registry_hash(cgocopy_registry_node_name(reversed)) & (count - 1);
+            reversed->next = buckets[index];
+            buckets[index] = reversed;
+            reversed = next;
+        }
+    }
+
+    free(cgocopy_registry_buckets);
+    cgocopy_registry_buckets = buckets;
+    cgocopy_registry_bucket_count = count;
+}
+
+bool cgocopy_registry_add(cgocopy_struct_registry_node *node) {
+    if (node == NULL) {
+        return false;
+    }
+
+    bool added = false;
+    pthread_rwlock_wrlock(&cgocopy_registry_lock);
+
+    if (cgocopy_registry_node_count >= cgocopy_registry_bucket_count * 3 / 4) {
+        cgocopy_registry_grow();
+    }
+
+    // Without buckets the first table could not be allocated: report it
+    // rather than dropping the node silently
+    if (cgocopy_registry_bucket_count > 0) {
+        cgocopy_struct_registry_node **bucket = cgocopy_registry_bucket(cgocopy_registry_node_name(node));
+        bool linked = false;
+        for (cgocopy_struct_registry_node *cursor = *bucket; cursor != NULL; cursor = cursor->next) {
+            if (cursor == node) {
+                linked = true;
+                break;
+            }
+        }
+        if (!linked) {
+            node->next = *bucket;
+            *bucket = node;
+            cgocopy_registry_node_count++;
+        }
+        added = true;
+    }
+
+    pthread_rwlock_unlock(&cgocopy_registry_lock);
+    return added;
+}
+
+bool cgocopy_registry_remove(cgocopy_struct_registry_node *node) {
+    if (node == NULL) {
+        return false;
+    }
+
+    bool removed = false;
+    pthread_rwlock_wrlock(&cgocopy_registry_lock);
+
+    if (cgocopy_registry_bucket_count > 0) {
+        cgocopy_struct_registry_node **link = cgocopy_registry_bucket(cgocopy_registry_node_name(node));
+        while (*link != NULL) {
+            if (*link == node) {
+                *link = node->next;
+                node->next = NULL;
+                cgocopy_registry_node_count--;
+                removed = true;
+                break;
+            }
+            link = &(*link)->next;
+        }
+    }
+
+    pthread_rwlock_unlock(&cgocopy_registry_lock);
+    return removed;
+}
+
+bool cgocopy_unregister_struct_info(const char *name) {
+    if (name == NULL) {
+        return false;
+    }
+
+    bool removed = false;
+    pthread_rwlock_wrlock(&cgocopy_registry_lock);
+
+    if (cgocopy_registry_bucket_count > 0) {
+        cgocopy_struct_registry_node **link = cgocopy_registry_bucket(name);
+        while (*link != NULL) {
+            if (strcmp(cgocopy_registry_node_name(*link), name) == 0) {
+                cgocopy_struct_registry_node *node = *link;
+                *link = node->next;
+                node->next = NULL;
+                cgocopy_registry_node_count--;
+                removed = true;
+                break;
+            }
+            link = &(*link)->next;
+        }
+    }
+
+    pthread_rwlock_unlock(&cgocopy_registry_lock);
+    return removed;
 }
 
 const cgocopy_struct_info *cgocopy_lookup_struct_info(const char *name) {
     if (name == NULL) {
         return NULL;
     }
-    cgocopy_struct_registry_node *cursor = cgocopy_registry_head;
-    while (cursor != NULL) {
-        if (cursor->info != NULL && cursor->info->name != NULL && strcmp(cursor->info->name, name) == 0) {
-            return cursor->info;
+
+    const cgocopy_struct_info *info = NULL;
+    pthread_rwlock_rdlock(&cgocopy_registry_lock);
+
+    if (cgocopy_registry_bucket_count > 0) {
+        for (cgocopy_struct_registry_node *cursor = *cgocopy_registry_bucket(name); cursor != NULL;
+             cursor = cursor->next) {
+            if (strcmp(cgocopy_registry_node_name(cursor), name) == 0) {
+                info = cursor->info;
+                break;
+            }
+        }
+    }
+
+    pthread_rwlock_unlock(&cgocopy_registry_lock);
+    return info;
+}
+
+static size_t cgocopy_string_size(const char *s) {
+    return s == NULL ? 0 : strlen(s) + 1;
+}
+
+// Copies s to *cursor and advances it past the copy.
+static const char *cgocopy_copy_string(const char *s, char **cursor) {
+    if (s == NULL) {
+        return NULL;
+    }
+    size_t size = strlen(s) + 1;
+    char *copy = memcpy(*cursor, s, size);
+    *cursor += size;
+    return copy;
+}
+
+// Copies info, its fields and their strings into one allocation. Caller
+// holds the lock.
+static cgocopy_struct_info *cgocopy_struct_info_dup(const cgocopy_struct_info *info) {
+    size_t field_count = info->fields == NULL ? 0 : info->field_count;
+    size_t size = sizeof(*info) + field_count * sizeof(cgocopy_field_info) + cgocopy_string_size(info->name);
+    for (size_t i = 0; i < field_count; i++) {
+        const cgocopy_field_info *field = &info->fields[i];
+        size += cgocopy_string_size(field->name) + cgocopy_string_size(field->type_name) +
+                cgocopy_string_size(field->elem_type);
+    }
+
+    cgocopy_struct_info *copy = malloc(size);
+    if (copy == NULL) {
+        return NULL;
+    }
+
+    // Fields follow the struct, strings follow the fields
+    cgocopy_field_info *fields = (cgocopy_field_info *)(copy + 1);
+    char *cursor = (char *)(fields + field_count);
+    for (size_t i = 0; i < field_count; i++) {
+        fields[i] = info->fields[i];
+        fields[i].name = cgocopy_copy_string(info->fields[i].name, &cursor);
+        fields[i].type_name = cgocopy_copy_string(info->fields[i].type_name, &cursor);
+        fields[i].elem_type = cgocopy_copy_string(info->fields[i].elem_type, &cursor);
+    }
+
+    *copy = *info;
+    copy->name = cgocopy_copy_string(info->name, &cursor);
+    copy->field_count = field_count;
+    copy->fields = field_count == 0 ? NULL : fields;
+    return copy;
+}
+
+cgocopy_struct_info *cgocopy_copy_struct_info(const char *name) {
+    if (name == NULL) {
+        return NULL;
+    }
+
+    cgocopy_struct_info *copy = NULL;
+    pthread_rwlock_rdlock(&cgocopy_registry_lock);
+
+    if (cgocopy_registry_bucket_count > 0) {
+        for (cgocopy_struct_registry_node *cursor = *cgocopy_registry_bucket(name); cursor != NULL;
+             cursor = cursor->next) {
+            if (strcmp(cgocopy_registry_node_name(cursor), name) == 0) {
+                if (cursor->info != NULL) {
+                    copy = cgocopy_struct_info_dup(cursor->info);
+                }
+                break;
+            }
         }
-        cursor = cursor->next;
     }
-    return NULL;
+
+    pthread_rwlock_unlock(&cgocopy_registry_lock);
+    return copy;
+}
+
+// Reports whether node is the newest registration of its name, i.e. not
+// shadowed by a node earlier in its chain. Caller holds the lock.
+static bool cgocopy_registry_visible(cgocopy_struct_registry_node *chain, cgocopy_struct_registry_node *node) {
+    const char *name = cgocopy_registry_node_name(node);
+    for (cgocopy_struct_registry_node *cursor = chain; cursor != node; cursor = cursor->next) {
+        if (strcmp(cgocopy_registry_node_name(cursor), name) == 0) {
+            return false;
+        }
+    }
+    return true;
+}
+
+size_t cgocopy_registry_list(const cgocopy_struct_info **out, size_t capacity) {
+    size_t total = 0;
+    pthread_rwlock_rdlock(&cgocopy_registry_lock);
+
+    for (size_t i = 0; i < cgocopy_registry_bucket_count; i++) {
+        cgocopy_struct_registry_node *chain = cgocopy_registry_buckets[i];
+        for (cgocopy_struct_registry_node *cursor = chain; cursor != NULL; cursor = cursor->next) {
+            if (cursor->info == NULL || !cgocopy_registry_visible(chain, cursor)) {
+                continue;
+            }
+            if (out != NULL && total < capacity) {
+                out[total] = cursor->info;
+            }
+            total++;
+        }
+    }
+
+    pthread_rwlock_unlock(&cgocopy_registry_lock);
+    return total;
 }
@@ -0,0 +1,210 @@
+package cgocopy2
+
+/*
+#include <stdlib.h>
+#include "../../native/cgocopy_metadata.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+// PrecompileAuto registers T using C metadata from the native metadata
+// registry, with no per-type getter or conversion code.
+// See Registry.PrecompileAuto.
+//
+// Example:
+//
+//	// In the C preamble (see native/cgocopy_metadata.h):
+//	//   CGOCOPY_STRUCT_BEGIN(Device)
+//	//       CGOCOPY_FIELD_PRIMITIVE(Device, id, uint32_t),
+//	//       CGOCOPY_FIELD_STRING(Device, name)
+//	//   CGOCOPY_STRUCT_END(Device)
+//
+//	type Device struct {
+//	    ID   uint32 `cgocopy:"id"`
+//	    Name string `cgocopy:"name"`
+//	}
+//
+//	func init() {
+//	    if err := cgocopy2.PrecompileAuto[Device](); err != nil {
+//	        panic(err)
+//	    }
+//	}
+func PrecompileAuto[T any]() error {
+	return globalRegistry.PrecompileAuto(reflect.TypeFor[T]())
+}
+
+// PrecompileAuto registers goType in r using the C metadata that C code
+// registered at load time with the CGOCOPY_STRUCT_BEGIN/END macros of
+// native/cgocopy_metadata.h.
+//
+// The C type name is the `cgocopy` tag of a blank field, if the struct has
+// one, and otherwise the Go type name:
+//
+//	type Particle struct {
+//	    _  struct{} `cgocopy:"particle_t"`
+//	    ID int32    `cgocopy:"id"`
+//	}
+//
+// The metadata of nested, element and pointee struct types found in the
+// native registry is added to r's C metadata catalog, so those types are
+// registered too (see AddCMetadata). Returns an error wrapping
+// ErrMetadataNotFound if the C type was never registered natively.
+func (r *Registry) PrecompileAuto(goType reflect.Type) error {
+	if goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+	if goType.Kind() != reflect.Struct {
+		return newRegistrationError(goType, "not a struct type", ErrInvalidType)
+	}
+
+	cTypeName := autoCTypeName(goType)
+	cInfo, ok := LookupNativeCMetadata(cTypeName)
+	if !ok {
+		return newRegistrationError(goType,
+			fmt.Sprintf("C type %q not found in the native metadata registry", cTypeName),
+			ErrMetadataNotFound)
+	}
+
+	r.AddCMetadata(nativeDependencies(cInfo)...)
+	return r.PrecompileWithC(goType, cInfo)
+}
+
+// autoCTypeName returns the C type name PrecompileAuto looks up for goType.
+func autoCTypeName(goType reflect.Type) string {
+	for i := 0; i < goType.NumField(); i++ {
+		field := goType.Field(i)
+		if field.Name != "_" {
+			continue
+		}
+		if cName, skip := parseTag(field.Tag.Get("cgocopy")); cName != "" && !skip {
+			return cName
+		}
+	}
+	return goType.Name()
+}
+
+// nativeDependencies returns the native metadata of every struct type that
+// cInfo refers to, directly or transitively.
+func nativeDependencies(cInfo CStructInfo) []CStructInfo {
+	var deps []CStructInfo
+	seen := map[string]bool{cInfo.Name: true}
+
+	var walk func(info CStructInfo)
+	walk = func(info CStructInfo) {
+		for _, field := range info.Fields {
+			name := cStructTypeName(field.Type)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if nested, ok := LookupNativeCMetadata(name); ok {
+				deps = append(deps, nested)
+				walk(nested)
+			}
+		}
+	}
+	walk(cInfo)
+
+	return deps
+}
+
+// LookupNativeCMetadata returns the metadata C code registered for a C type
+// in the native metadata registry, converted to CStructInfo. The result can
+// be passed to PrecompileWithC, AddCMetadata or CopyToMap.
+//
+// The metadata is copied under the registry lock, so the result stays valid
+// even if C code unregisters and frees it concurrently.
+func LookupNativeCMetadata(cTypeName string) (CStructInfo, bool) {
+	cName := C.CString(cTypeName)
+	defer C.free(unsafe.Pointer(cName))
+
+	info := C.cgocopy_copy_struct_info(cName)
+	if info == nil {
+		return CStructInfo{}, false
+	}
+	defer C.free(unsafe.Pointer(info))
+	return convertNativeCMetadata(info), true
+}
+
+// NativeCTypeNames returns the names of all C types in the native metadata
+// registry, sorted. Types shadowed by a newer registration of the same name
+// are listed once.
+//
+// The names are read after the registry lock is released, so C code must not
+// free the metadata of a type while NativeCTypeNames may be running.
+func NativeCTypeNames() []string {
+	for {
+		count := C.cgocopy_registry_list(nil, 0)
+		if count == 0 {
+			return nil
+		}
+
+		infos := make([]*C.cgocopy_struct_info, count)
+		total := C.cgocopy_registry_list(&infos[0], count)
+		if total > count {
+			// Registered concurrently: retry with room for the new types
+			continue
+		}
+
+		names := make([]string, 0, total)
+		for _, info := range infos[:total] {
+			names = append(names, C.GoString(info.name))
+		}
+		sort.Strings(names)
+		return names
+	}
+}
+
+// UnregisterNativeCMetadata removes the newest registration of a C type from
+// the native metadata registry, revealing any older one, and reports whether
+// there was one. Types already registered in a Go Registry are unaffected.
+func UnregisterNativeCMetadata(cTypeName string) bool {
+	cName := C.CString(cTypeName)
+	defer C.free(unsafe.Pointer(cName))
+
+	return bool(C.cgocopy_unregister_struct_info(cName))
+}
+
+// convertNativeCMetadata converts native struct metadata to CStructInfo,
+// using the type names of the cgocopy_macros.h macros where they differ
+// ("string" for char*, "elem[]" for arrays).
+func convertNativeCMetadata(info *C.cgocopy_struct_info) CStructInfo {
+	count := int(info.field_count)
+	cFields := unsafe.Slice(info.fields, count)
+
+	fields := make([]CFieldInfo, count)
+	for i := range cFields {
+		cField := &cFields[i]
+		field := CFieldInfo{
+			Name:   C.GoString(cField.name),
+			Type:   C.GoString(cField.type_name),
+			Offset: uintptr(cField.offset),
+			Size:   uintptr(cField.size),
+		}
+
+		switch cField.kind {
+		case C.CGOCOPY_FIELD_STRING_KIND:
+			field.Type = "string"
+			field.IsPointer = true
+		case C.CGOCOPY_FIELD_POINTER_KIND:
+			field.IsPointer = true
+		case C.CGOCOPY_FIELD_ARRAY_KIND:
+			field.Type = C.GoString(cField.elem_type) + "[]"
+			field.IsArray = true
+			field.ArrayLen = int(cField.elem_count)
+		}
+		fields[i] = field
+	}
+
+	return CStructInfo{
+		Name:   C.GoString(info.name),
+		Size:   uintptr(info.size),
+		Fields: fields,
+	}
+}
@@ -0,0 +1,175 @@
+package cgocopy2
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// AddCMetadata adds C struct metadata to the global registry's catalog.
+// See Registry.AddCMetadata.
+func AddCMetadata(infos ...CStructInfo) {
+	globalRegistry.AddCMetadata(infos...)
+}
+
+// AddCMetadata makes C struct metadata available to PrecompileWithC for
+// resolving nested types by C type name. It does not register any Go type.
+//
+// When PrecompileWithC meets a nested struct, array/slice element, pointer or
+// Lazy target type that is not registered yet, it looks up the C metadata of
+// that type here and registers it too. The C type name is taken from the C
+// field's type when it names a struct (e.g. "Point3D", "Point3D*" or the
+// "Point3D[]" of an array field), and defaults to the Go type name for the
+// generic "struct" and "pointer". A C field whose type names a scalar or
+// string is an error wrapping ErrMetadataNotFound.
+//
+// A nested type without catalog metadata is left unregistered, so it can
+// still be registered with its own PrecompileWithC call afterwards. Until it
+// is, Validate reports it and copies fail with ErrNotRegistered.
+//
+// Example:
+//
+//	cgocopy2.AddCMetadata(
+//	    extractCMetadata(C.get_Point3D_metadata()),
+//	)
+//	// Registers Point3D as well
+//	cgocopy2.PrecompileWithC[GameObject](extractCMetadata(C.get_GameObject_metadata()))
+func (r *Registry) AddCMetadata(infos ...CStructInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, info := range infos {
+		r.cInfos[info.Name] = info
+	}
+}
+
+// lookupCMetadata returns catalog metadata for a C type name.
+func (r *Registry) lookupCMetadata(cTypeName string) (CStructInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	info, ok := r.cInfos[cTypeName]
+	return info, ok
+}
+
+// registrationPlan collects a type and the unregistered struct types it
+// depends on, in dependency order (dependencies first).
+type registrationPlan struct {
+	registry *Registry
+	pending  []*StructMetadata
+	visited  map[reflect.Type]bool
+}
+
+func newRegistrationPlan(registry *Registry) *registrationPlan {
+	return &registrationPlan{registry: registry, visited: make(map[reflect.Type]bool)}
+}
+
+// add analyzes goType (with C metadata when cInfo is non-nil) and, before
+// it, every nested type it reaches that is not registered yet. Types already
+// in the plan are skipped, so self-referential types terminate.
+func (p *registrationPlan) add(goType reflect.Type, cInfo *CStructInfo) error {
+	p.visited[goType] = true
+
+	var metadata *StructMetadata
+	var err error
+	if cInfo != nil {
+		metadata, err = analyzeStructWithC(goType, *cInfo)
+	} else {
+		metadata, err = analyzeStruct(goType)
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := range metadata.Fields {
+		field := &metadata.Fields[i]
+		nestedType := nestedStructType(field)
+		if nestedType == nil || p.visited[nestedType] || p.registry.IsRegistered(nestedType) {
+			continue
+		}
+
+		var nestedInfo *CStructInfo
+		if cInfo != nil {
+			cTypeName, err := nestedCTypeName(field, nestedType)
+			if err != nil {
+				return err
+			}
+			info, ok := p.registry.lookupCMetadata(cTypeName)
+			if !ok {
+				// Left for the caller to register later, in any order;
+				// Validate reports it until then
+				continue
+			}
+			nestedInfo = &info
+		}
+
+		if err := p.add(nestedType, nestedInfo); err != nil {
+			return fmt.Errorf("nested type %s: %w", nestedType, err)
+		}
+	}
+
+	p.pending = append(p.pending, metadata)
+	return nil
+}
+
+// nestedCTypeName returns the C type name used to find the C metadata of a
+// nested type: the C field's struct type, or the Go name when the C type is
+// the generic "struct" or "pointer". A C type that names a scalar or string
+// cannot hold the nested struct and is an error.
+func nestedCTypeName(field *FieldInfo, nestedType reflect.Type) (string, error) {
+	name := cStructTypeName(field.CType)
+	switch name {
+	case "", "struct", "pointer":
+		return nestedType.Name(), nil
+	}
+	if _, scalar := cScalarKind(name); scalar || isCStringType(name) || name == "void" {
+		return "", fmt.Errorf("%w: C field %s has type %q, which does not name a struct for %s",
+			ErrMetadataNotFound, field.CName, field.CType, nestedType)
+	}
+	return name, nil
+}
+
+// cStructTypeName returns the struct name in a C field type, without the
+// array suffix, pointer, const and struct keyword ("Point[]", "const
+// struct Point*" and "Point" all give "Point").
+func cStructTypeName(cType string) string {
+	name := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(cType), "[]"))
+	name = strings.TrimSpace(strings.TrimSuffix(name, "*"))
+	name = strings.TrimSpace(strings.TrimPrefix(name, "const "))
+	return strings.TrimSpace(strings.TrimPrefix(name, "struct "))
+}
+
+// registerAll adds the metadata of a plan in one step, so either every type
+// is registered or none is.
+func (r *Registry) registerAll(pending []*StructMetadata) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sealed.Load() != nil {
+		return newRegistrationError(pending[len(pending)-1].GoType, "registry is sealed", ErrRegistrySealed)
+	}
+
+	// Check C names against the registry and within the plan before
+	// registering anything
+	planned := make(map[string]reflect.Type, len(pending))
+	for _, metadata := range pending {
+		if !hasCName(metadata.GoType, metadata) {
+			continue
+		}
+		if err := r.checkCName(metadata.GoType, metadata); err != nil {
+			return err
+		}
+		if existing, ok := planned[metadata.CTypeName]; ok {
+			return newRegistrationError(metadata.GoType,
+				fmt.Sprintf("C name %q is also used by %s", metadata.CTypeName, qualifiedTypeName(existing)),
+				ErrDuplicateCName)
+		}
+		planned[metadata.CTypeName] = metadata.GoType
+	}
+
+	for _, metadata := range pending {
+		r.metadata[metadata.GoType] = metadata
+		r.mapCName(metadata.GoType, metadata)
+	}
+	return nil
+}
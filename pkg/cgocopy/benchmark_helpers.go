@@ -93,40 +93,3 @@ func CreateGameObject(id int32, name string, health float32, level int32) unsafe
 func FreeCPointer(ptr unsafe.Pointer) {
 	C.free(ptr)
 }
-
-// Register types for cgocopy
-func init() {
-	PrecompileWithC[BenchPerson](CStructInfo{
-		Name: "BenchPerson",
-		Size: unsafe.Sizeof(BenchPerson{}),
-		Fields: []CFieldInfo{
-			{Name: "id", Type: "int32", Offset: 0, Size: 4},
-			{Name: "name", Type: "int8", Offset: 4, Size: 1, IsArray: true, ArrayLen: 64},
-			{Name: "balance", Type: "float64", Offset: 72, Size: 8},
-			{Name: "active", Type: "int32", Offset: 80, Size: 4},
-		},
-	})
-
-	PrecompileWithC[Point3DBench](CStructInfo{
-		Name: "Point3D",
-		Size: unsafe.Sizeof(Point3DBench{}),
-		Fields: []CFieldInfo{
-			{Name: "x", Type: "float64", Offset: 0, Size: 8},
-			{Name: "y", Type: "float64", Offset: 8, Size: 8},
-			{Name: "z", Type: "float64", Offset: 16, Size: 8},
-		},
-	})
-
-	PrecompileWithC[GameObjectBench](CStructInfo{
-		Name: "GameObject",
-		Size: unsafe.Sizeof(GameObjectBench{}),
-		Fields: []CFieldInfo{
-			{Name: "id", Type: "int32", Offset: 0, Size: 4},
-			{Name: "name", Type: "int8", Offset: 4, Size: 1, IsArray: true, ArrayLen: 64},
-			{Name: "position", Type: "struct", Offset: 72, Size: 24},
-			{Name: "velocity", Type: "struct", Offset: 96, Size: 24},
-			{Name: "health", Type: "float32", Offset: 120, Size: 4},
-			{Name: "level", Type: "int32", Offset: 124, Size: 4},
-		},
-	})
-}
@@ -0,0 +1,147 @@
+package cgocopy2
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"runtime/trace"
+	"sync"
+	"unsafe"
+)
+
+// parallelChunkSize is the number of elements a worker copies per unit of
+// work; it bounds how late cancellation is noticed.
+const parallelChunkSize = 1024
+
+// CopyArrayParallel copies n contiguous C structs starting at cArray into a
+// new []T, splitting the work across at most workers goroutines.
+// If workers <= 0, runtime.GOMAXPROCS(0) is used.
+//
+// Elements are laid out in C memory with a stride of the registered C struct
+// size (StructMetadata.Size). The result preserves C order; each worker writes
+// only its own elements of one preallocated slice.
+//
+// Copying stops early when ctx is cancelled or an element fails. Failures are
+// returned as an *ElementError carrying the element index; if several workers
+// fail, the error with the lowest index among those observed is returned.
+//
+// Example:
+//
+//	users, err := cgocopy2.CopyArrayParallel[User](ctx, unsafe.Pointer(cUsers), count, 0)
+//	var elemErr *cgocopy2.ElementError
+//	if errors.As(err, &elemErr) {
+//	    log.Printf("user %d is corrupt: %v", elemErr.Index, elemErr.Cause)
+//	}
+func CopyArrayParallel[T any](ctx context.Context, cArray unsafe.Pointer, n int, workers int) ([]T, error) {
+	return CopyArrayParallelWith[T](ctx, globalRegistry, cArray, n, workers)
+}
+
+// CopyArrayParallelWith is like CopyArrayParallel but resolves T, and every
+// nested type, in reg.
+func CopyArrayParallelWith[T any](ctx context.Context, reg *Registry, cArray unsafe.Pointer, n int, workers int) ([]T, error) {
+	goType := reflect.TypeFor[T]()
+
+	if goType.Kind() != reflect.Struct {
+		return nil, newCopyError(goType, "", "only struct types can be bulk copied", ErrInvalidType)
+	}
+	if n < 0 {
+		return nil, newCopyError(goType, "", "negative element count", ErrInvalidType)
+	}
+	if n == 0 {
+		return []T{}, nil
+	}
+	if cArray == nil {
+		return nil, ErrNilPointer
+	}
+
+	metadata := reg.Get(goType)
+	if metadata == nil {
+		return nil, newCopyError(goType, "", "type not registered", ErrNotRegistered)
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	chunks := (n + parallelChunkSize - 1) / parallelChunkSize
+	if workers > chunks {
+		workers = chunks
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if trace.IsEnabled() {
+		var task *trace.Task
+		ctx, task = trace.NewTask(ctx, "cgocopy.CopyArrayParallel")
+		defer task.End()
+	}
+
+	result := make([]T, n)
+
+	var (
+		mu       sync.Mutex
+		firstErr *ElementError
+		next     int
+		wg       sync.WaitGroup
+	)
+
+	fail := func(index int, err error) {
+		mu.Lock()
+		if firstErr == nil || index < firstErr.Index {
+			firstErr = &ElementError{Index: index, Cause: err}
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	// claim hands out the next chunk start index, or -1 when done
+	claim := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		if next >= n {
+			return -1
+		}
+		start := next
+		next += parallelChunkSize
+		return start
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				start := claim()
+				if start < 0 || ctx.Err() != nil {
+					return
+				}
+
+				end := min(start+parallelChunkSize, n)
+				region := traceRegion(ctx, "CopyArrayParallel", metadata.TypeName)
+				for i := start; i < end; i++ {
+					cPtr := unsafe.Pointer(uintptr(cArray) + uintptr(i)*metadata.Size)
+					dst := reflect.ValueOf(&result[i]).Elem()
+					if err := copyTopLevel(reg, dst, cPtr, metadata, Limits{}); err != nil {
+						endTraceRegion(region)
+						fail(i, err)
+						return
+					}
+				}
+				endTraceRegion(region)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		// Only reachable through the parent context; our own cancel runs on
+		// failure, which is reported above.
+		return nil, err
+	}
+
+	return result, nil
+}
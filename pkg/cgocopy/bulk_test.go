@@ -0,0 +1,127 @@
+package cgocopy2
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+type BulkRecord struct {
+	ID    int32
+	Score float64
+	Name  string
+}
+
+func TestCopyArrayParallel(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[BulkRecord](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	const n = 5000
+	cArray := make([]BulkRecord, n)
+	for i := range cArray {
+		cArray[i] = BulkRecord{ID: int32(i), Score: float64(i) / 2}
+	}
+
+	for _, workers := range []int{0, 1, 3, 64} {
+		result, err := CopyArrayParallel[BulkRecord](context.Background(), unsafe.Pointer(&cArray[0]), n, workers)
+		if err != nil {
+			t.Fatalf("CopyArrayParallel(workers=%d) error = %v", workers, err)
+		}
+		if len(result) != n {
+			t.Fatalf("len(result) = %d, want %d", len(result), n)
+		}
+		for i := range result {
+			if result[i].ID != int32(i) || result[i].Score != float64(i)/2 {
+				t.Fatalf("workers=%d: result[%d] = %+v, want ID %d", workers, i, result[i], i)
+			}
+		}
+	}
+}
+
+func TestCopyArrayParallel_Empty(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	result, err := CopyArrayParallel[BulkRecord](context.Background(), nil, 0, 4)
+	if err != nil || len(result) != 0 {
+		t.Errorf("CopyArrayParallel(n=0) = %v, %v, want empty slice and nil", result, err)
+	}
+}
+
+func TestCopyArrayParallel_NotRegistered(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	cArray := make([]BulkRecord, 3)
+	_, err := CopyArrayParallel[BulkRecord](context.Background(), unsafe.Pointer(&cArray[0]), 3, 2)
+	if !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("CopyArrayParallel() error = %v, want ErrNotRegistered", err)
+	}
+}
+
+func TestCopyArrayParallel_NonStruct(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	cArray := make([]BulkRecord, 3)
+	if _, err := CopyArrayParallel[int](context.Background(), unsafe.Pointer(&cArray[0]), 3, 2); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("CopyArrayParallel[int]() error = %v, want ErrInvalidType", err)
+	}
+	// An interface type argument has no zero value to take the type of
+	if _, err := CopyArrayParallel[any](context.Background(), unsafe.Pointer(&cArray[0]), 3, 2); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("CopyArrayParallel[any]() error = %v, want ErrInvalidType", err)
+	}
+}
+
+func TestCopyArrayParallel_ElementError(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[LimitedRecord](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+	if err := SetTypeLimits[LimitedRecord](Limits{MaxElements: 2}); err != nil {
+		t.Fatalf("SetTypeLimits() error = %v", err)
+	}
+
+	const n = 3000
+	const bad = 2500
+	cArray := make([]LimitedRecord, n)
+	cArray[bad].Items = []int32{1, 2, 3}
+
+	_, err := CopyArrayParallel[LimitedRecord](context.Background(), unsafe.Pointer(&cArray[0]), n, 4)
+
+	var elemErr *ElementError
+	if !errors.As(err, &elemErr) {
+		t.Fatalf("CopyArrayParallel() error = %v, want ElementError", err)
+	}
+	if elemErr.Index != bad {
+		t.Errorf("ElementError.Index = %d, want %d", elemErr.Index, bad)
+	}
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("error should wrap ErrLimitExceeded, got %v", err)
+	}
+}
+
+func TestCopyArrayParallel_Cancelled(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[BulkRecord](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cArray := make([]BulkRecord, 10)
+	_, err := CopyArrayParallel[BulkRecord](ctx, unsafe.Pointer(&cArray[0]), len(cArray), 2)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("CopyArrayParallel() error = %v, want context.Canceled", err)
+	}
+}
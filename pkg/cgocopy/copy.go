@@ -1,7 +1,12 @@
 package cgocopy2
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
 	"reflect"
+	"time"
 	"unsafe"
 )
 
@@ -25,6 +30,86 @@ import (
 //	    fmt.Printf("User: %+v\n", user)
 //	}
 func Copy[T any](cPtr unsafe.Pointer) (T, error) {
+	return copyWithLimits[T](globalRegistry, cPtr, Limits{})
+}
+
+// CopyWith is like Copy but resolves T, and every nested type, in reg
+// instead of the global registry.
+//
+// Example:
+//
+//	reg := cgocopy2.NewRegistry()
+//	reg.PrecompileWithC(reflect.TypeFor[User](), userInfo)
+//	user, err := cgocopy2.CopyWith[User](reg, cPtr)
+func CopyWith[T any](reg *Registry, cPtr unsafe.Pointer) (T, error) {
+	return copyWithLimits[T](reg, cPtr, Limits{})
+}
+
+// CopyWithLimits is like Copy but applies per-call resource limits on top of
+// the type limits (SetTypeLimits) and the global defaults (SetDefaultLimits).
+// Non-zero fields of limits take precedence.
+//
+// Example:
+//
+//	user, err := cgocopy2.CopyWithLimits[User](cPtr, cgocopy2.Limits{MaxStringLen: 256})
+//	if errors.Is(err, cgocopy2.ErrLimitExceeded) {
+//	    // corrupt or hostile C data
+//	}
+func CopyWithLimits[T any](cPtr unsafe.Pointer, limits Limits) (T, error) {
+	return copyWithLimits[T](globalRegistry, cPtr, limits)
+}
+
+// CopyWithLimitsWith is like CopyWithLimits but resolves T, every nested
+// type and the type limits in reg.
+func CopyWithLimitsWith[T any](reg *Registry, cPtr unsafe.Pointer, limits Limits) (T, error) {
+	return copyWithLimits[T](reg, cPtr, limits)
+}
+
+// CopyByCName copies the C struct at cPtr into a fresh value of whichever Go
+// type was registered for the C type name, and returns a pointer to it (e.g.
+// *User). It lets callers that only know the C type at runtime, such as a
+// message dispatcher, copy without a type switch.
+//
+// Example:
+//
+//	msg, err := cgocopy2.CopyByCName(C.GoString(typeName), payload)
+//	if err != nil {
+//	    return err
+//	}
+//	switch m := msg.(type) {
+//	case *Login:
+//	    handleLogin(m)
+//	}
+func CopyByCName(cTypeName string, cPtr unsafe.Pointer) (any, error) {
+	return globalRegistry.CopyByCName(cTypeName, cPtr)
+}
+
+// CopyByCName is like the package-level CopyByCName but resolves the C type
+// name, and every nested type, in r.
+func (r *Registry) CopyByCName(cTypeName string, cPtr unsafe.Pointer) (any, error) {
+	if cPtr == nil {
+		return nil, ErrNilPointer
+	}
+
+	metadata := r.GetByCName(cTypeName)
+	if metadata == nil {
+		return nil, fmt.Errorf("no Go type registered for C type %q: %w", cTypeName, ErrNotRegistered)
+	}
+
+	result := reflect.New(metadata.GoType)
+	region := traceRegion(context.Background(), "CopyByCName", metadata.TypeName)
+	err := copyTopLevel(r, result.Elem(), cPtr, metadata, Limits{})
+	endTraceRegion(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Interface(), nil
+}
+
+// copyWithLimits implements Copy, CopyWith, CopyWithLimits and
+// CopyWithLimitsWith.
+func copyWithLimits[T any](registry *Registry, cPtr unsafe.Pointer, limits Limits) (T, error) {
 	var zero T
 
 	// Check for nil pointer
@@ -38,7 +123,7 @@ func Copy[T any](cPtr unsafe.Pointer) (T, error) {
 		goType = goType.Elem()
 	}
 
-	metadata := globalRegistry.Get(goType)
+	metadata := registry.Get(goType)
 	if metadata == nil {
 		return zero, newCopyError(goType, "", "type not registered", ErrNotRegistered)
 	}
@@ -46,6 +131,37 @@ func Copy[T any](cPtr unsafe.Pointer) (T, error) {
 	// Create a new instance
 	result := reflect.New(goType).Elem()
 
+	region := traceRegion(context.Background(), "Copy", metadata.TypeName)
+	err := copyTopLevel(registry, result, cPtr, metadata, limits)
+	endTraceRegion(region)
+	if err != nil {
+		return zero, err
+	}
+
+	return result.Interface().(T), nil
+}
+
+// copyTopLevel copies the C struct at cPtr into dst, an addressable value of
+// the type described by metadata in registry. Per-call limits are merged with
+// the type and global limits. Field failures are wrapped in a CopyError.
+func copyTopLevel(registry *Registry, dst reflect.Value, cPtr unsafe.Pointer, metadata *StructMetadata, limits Limits) error {
+	state := newCopyState(registry, limits.merge(metadata.Limits).merge(DefaultLimits()))
+	state.call = limits
+	state.startLogging(metadata)
+
+	metrics := loadMetrics()
+	if metrics == nil {
+		return state.copyTopLevel(dst, cPtr, metadata)
+	}
+
+	start := time.Now()
+	err := state.copyTopLevel(dst, cPtr, metadata)
+	recordCopy(metrics, metadata, state, start, err)
+	return err
+}
+
+// copyTopLevel copies every field of a top-level struct and runs its hooks.
+func (s *copyState) copyTopLevel(dst reflect.Value, cPtr unsafe.Pointer, metadata *StructMetadata) error {
 	// Copy each field
 	for i := range metadata.Fields {
 		field := &metadata.Fields[i]
@@ -54,22 +170,158 @@ func Copy[T any](cPtr unsafe.Pointer) (T, error) {
 		}
 
 		// Get field value in result struct
-		resultField := result.Field(field.Index)
+		resultField := goFieldValue(dst, field)
 
 		// Calculate C field pointer (assuming same layout for now)
 		cFieldPtr := unsafe.Pointer(uintptr(cPtr) + field.Offset)
 
 		// Copy based on field type
-		if err := copyField(resultField, cFieldPtr, field); err != nil {
-			return zero, newCopyError(goType, field.Name, "failed to copy field", err)
+		if err := s.copyLoggedField(resultField, cFieldPtr, field); err != nil {
+			path, cause := splitFieldPath(err)
+			return newCopyError(metadata.GoType, joinFieldPath(field.Name, path), "failed to copy field", cause)
 		}
 	}
 
-	return result.Interface().(T), nil
+	if err := runCopyHooks(dst, metadata); err != nil {
+		return newCopyError(metadata.GoType, "", "post-copy hook failed", err)
+	}
+
+	return nil
+}
+
+// copyState carries per-call state (registry, limits and usage so far)
+// through a copy.
+type copyState struct {
+	// registry resolves nested, element and pointee struct types.
+	registry *Registry
+
+	// limits apply to the struct being copied, and call holds the per-call
+	// limits.
+	limits Limits
+	call   Limits
+
+	// scopes saves the limits and logger of enclosing structs while a nested
+	// type with its own type limits or logger is copied.
+	scopes []scope
+
+	// depth is the current struct nesting level (the top-level struct is 1).
+	depth int
+
+	// bytes is the number of bytes allocated so far for strings, slices,
+	// maps and pointer targets.
+	bytes int
+
+	// strings is the number of Go strings allocated so far.
+	strings int
+
+	// read is the number of C bytes read so far through pointers: string
+	// data, slice and map elements and pointer targets. The inline bytes
+	// of the top-level struct are not included.
+	read int
+
+	// logging is true while any debug logger is installed, logger receives
+	// debug logs of the current struct's fields (nil when it has none), and
+	// path is the Go field path of the field being copied.
+	logging bool
+	logger  *slog.Logger
+	path    []string
+}
+
+// scope is the state enter replaced when descending into a nested struct at
+// depth, restored by the matching leave.
+type scope struct {
+	depth  int
+	limits Limits
+	logger *slog.Logger
+}
+
+// newCopyState creates the state for a single top-level copy.
+func newCopyState(registry *Registry, limits Limits) *copyState {
+	return &copyState{registry: registry, limits: limits, depth: 1}
+}
+
+// checkElements enforces MaxElements for a slice or map of n entries.
+func (s *copyState) checkElements(n uint64) error {
+	if s.limits.MaxElements > 0 && n > uint64(s.limits.MaxElements) {
+		return fmt.Errorf("%w: %d elements exceeds MaxElements %d", ErrLimitExceeded, n, s.limits.MaxElements)
+	}
+	return nil
+}
+
+// allocate charges n bytes against MaxBytes.
+func (s *copyState) allocate(n uintptr) error {
+	s.bytes += int(n)
+	if s.limits.MaxBytes > 0 && s.bytes > s.limits.MaxBytes {
+		return fmt.Errorf("%w: allocations exceed MaxBytes %d", ErrLimitExceeded, s.limits.MaxBytes)
+	}
+	return nil
+}
+
+// allocateElements charges count elements of elemSize bytes against MaxBytes,
+// rejecting counts whose total would overflow the budget before multiplying.
+// Counts that cannot be allocated at all fail even without limits.
+func (s *copyState) allocateElements(count uint64, elemSize uintptr) error {
+	if count > math.MaxInt || (elemSize > 0 && count > math.MaxInt/uint64(elemSize)) {
+		return fmt.Errorf("%w: %d elements of %d bytes cannot be allocated", ErrLimitExceeded, count, elemSize)
+	}
+	if s.limits.MaxBytes > 0 && elemSize > 0 && count > uint64(s.limits.MaxBytes)/uint64(elemSize) {
+		return fmt.Errorf("%w: allocations exceed MaxBytes %d", ErrLimitExceeded, s.limits.MaxBytes)
+	}
+	return s.allocate(uintptr(count) * elemSize)
+}
+
+// enter records descent into a nested struct of the type described by
+// metadata (nil for C-only structs) and enforces MaxDepth. A type with its
+// own limits (SetTypeLimits) applies them to its fields, below the per-call
+// limits and above those of the enclosing structs, and a type with its own
+// logger (SetTypeDebugLogger) logs its fields there. Callers must call leave
+// when done.
+func (s *copyState) enter(metadata *StructMetadata) error {
+	s.depth++
+
+	hasLimits := metadata != nil && metadata.Limits != (Limits{})
+	var logger *slog.Logger
+	hasLogger := false
+	if s.logging && metadata != nil {
+		logger, hasLogger = typeDebugLogger(metadata)
+	}
+	if hasLimits || hasLogger {
+		s.scopes = append(s.scopes, scope{depth: s.depth, limits: s.limits, logger: s.logger})
+		if hasLimits {
+			s.limits = s.call.merge(metadata.Limits).merge(s.limits)
+		}
+		if hasLogger {
+			s.logger = logger
+		}
+	}
+
+	if s.limits.MaxDepth > 0 && s.depth > s.limits.MaxDepth {
+		return fmt.Errorf("%w: nesting depth exceeds MaxDepth %d", ErrLimitExceeded, s.limits.MaxDepth)
+	}
+	return nil
+}
+
+// leave undoes the matching enter.
+func (s *copyState) leave() {
+	if n := len(s.scopes); n > 0 && s.scopes[n-1].depth == s.depth {
+		s.limits = s.scopes[n-1].limits
+		s.logger = s.scopes[n-1].logger
+		s.scopes = s.scopes[:n-1]
+	}
+	s.depth--
+}
+
+// goFieldValue returns the Go struct field described by field, following
+// IndexPath for fields flattened from embedded structs.
+func goFieldValue(structValue reflect.Value, field *FieldInfo) reflect.Value {
+	if field.IndexPath != nil {
+		return structValue.FieldByIndex(field.IndexPath)
+	}
+	return structValue.Field(field.Index)
 }
 
 // copyField copies a single field from C to Go based on its type.
-func copyField(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) error {
+func (s *copyState) copyField(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) error {
 	if !goField.CanSet() {
 		return ErrInvalidType
 	}
@@ -79,19 +331,31 @@ func copyField(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) err
 		return copyPrimitive(goField, cPtr, field.ReflectType)
 
 	case FieldTypeString:
-		return copyString(goField, cPtr)
+		if *(*unsafe.Pointer)(cPtr) == nil {
+			return copyNull(goField, field)
+		}
+		return s.copyString(goField, cPtr)
 
 	case FieldTypeStruct:
-		return copyStruct(goField, cPtr, field.ReflectType)
+		return s.copyStruct(goField, cPtr, s.nestedMetadata(field, field.ReflectType))
 
 	case FieldTypeArray:
-		return copyArray(goField, cPtr, field)
+		return s.copyArray(goField, cPtr, field)
 
 	case FieldTypeSlice:
-		return copySlice(goField, cPtr, field)
+		return s.copySlice(goField, cPtr, field)
 
 	case FieldTypePointer:
-		return copyPointer(goField, cPtr, field)
+		return s.copyPointer(goField, cPtr, field)
+
+	case FieldTypeOpaque:
+		return copyOpaque(goField, cPtr, field.ReflectType)
+
+	case FieldTypeMap:
+		return s.copyMap(goField, cPtr, field)
+
+	case FieldTypeLazy:
+		return copyLazy(goField, cPtr, s.registry)
 
 	default:
 		return ErrUnsupportedType
@@ -141,7 +405,7 @@ func copyPrimitive(goField reflect.Value, cPtr unsafe.Pointer, fieldType reflect
 
 // copyString copies a C string (char*) to a Go string.
 // This assumes the C struct contains a char* pointer.
-func copyString(goField reflect.Value, cPtr unsafe.Pointer) error {
+func (s *copyState) copyString(goField reflect.Value, cPtr unsafe.Pointer) error {
 	// Read the char* pointer from the C struct
 	charPtr := *(*unsafe.Pointer)(cPtr)
 	
@@ -151,7 +415,9 @@ func copyString(goField reflect.Value, cPtr unsafe.Pointer) error {
 	}
 
 	// Convert C string to Go string
-	// Walk the C string to find its length
+	// Walk the C string to find its length, stopping at MaxStringLen so an
+	// unterminated string cannot walk off into unmapped memory
+	maxLen := s.limits.MaxStringLen
 	length := 0
 	for {
 		b := *(*byte)(unsafe.Pointer(uintptr(charPtr) + uintptr(length)))
@@ -159,7 +425,11 @@ func copyString(goField reflect.Value, cPtr unsafe.Pointer) error {
 			break
 		}
 		length++
+		if maxLen > 0 && length > maxLen {
+			return fmt.Errorf("%w: string longer than MaxStringLen %d", ErrLimitExceeded, maxLen)
+		}
 	}
+	s.read += length + 1 // including the NUL
 
 	// Create a Go string from the C bytes
 	if length == 0 {
@@ -167,6 +437,11 @@ func copyString(goField reflect.Value, cPtr unsafe.Pointer) error {
 		return nil
 	}
 
+	if err := s.allocate(uintptr(length)); err != nil {
+		return err
+	}
+	s.strings++
+
 	bytes := make([]byte, length)
 	for i := 0; i < length; i++ {
 		bytes[i] = *(*byte)(unsafe.Pointer(uintptr(charPtr) + uintptr(i)))
@@ -176,16 +451,20 @@ func copyString(goField reflect.Value, cPtr unsafe.Pointer) error {
 	return nil
 }
 
-// copyStruct copies a nested struct field.
-func copyStruct(goField reflect.Value, cPtr unsafe.Pointer, fieldType reflect.Type) error {
-	// Check if the nested struct is registered
-	metadata := globalRegistry.Get(fieldType)
+// copyStruct copies a nested struct described by metadata (nil if the struct
+// type is not registered).
+func (s *copyState) copyStruct(goField reflect.Value, cPtr unsafe.Pointer, metadata *StructMetadata) error {
 	if metadata == nil {
 		return ErrNotRegistered
 	}
 
+	defer s.leave()
+	if err := s.enter(metadata); err != nil {
+		return err
+	}
+
 	// Create a new instance of the nested struct
-	nestedStruct := reflect.New(fieldType).Elem()
+	nestedStruct := reflect.New(metadata.GoType).Elem()
 
 	// Copy each field of the nested struct
 	for i := range metadata.Fields {
@@ -194,20 +473,25 @@ func copyStruct(goField reflect.Value, cPtr unsafe.Pointer, fieldType reflect.Ty
 			continue
 		}
 
-		nestedField := nestedStruct.Field(field.Index)
+		nestedField := goFieldValue(nestedStruct, field)
 		nestedCPtr := unsafe.Pointer(uintptr(cPtr) + field.Offset)
 
-		if err := copyField(nestedField, nestedCPtr, field); err != nil {
-			return err
+		if err := s.copyLoggedField(nestedField, nestedCPtr, field); err != nil {
+			return withFieldPath(field.Name, err)
 		}
 	}
 
+	// Nested hooks run before the enclosing struct's hooks
+	if err := runCopyHooks(nestedStruct, metadata); err != nil {
+		return err
+	}
+
 	goField.Set(nestedStruct)
 	return nil
 }
 
 // copyArray copies a fixed-size array field.
-func copyArray(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) error {
+func (s *copyState) copyArray(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) error {
 	elemSize := field.ElemType.Size()
 	elemKind := field.ElemType.Kind()
 
@@ -228,7 +512,7 @@ func copyArray(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) err
 		for i := 0; i < field.ArrayLen; i++ {
 			elemPtr := unsafe.Pointer(uintptr(cPtr) + uintptr(i)*elemSize)
 			elemField := goField.Index(i)
-			if err := copyString(elemField, elemPtr); err != nil {
+			if err := s.copyString(elemField, elemPtr); err != nil {
 				return err
 			}
 		}
@@ -237,11 +521,12 @@ func copyArray(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) err
 
 	// For struct arrays
 	if elemKind == reflect.Struct {
+		elemMeta := s.nestedMetadata(field, field.ElemType)
 		for i := 0; i < field.ArrayLen; i++ {
 			elemPtr := unsafe.Pointer(uintptr(cPtr) + uintptr(i)*elemSize)
 			elemField := goField.Index(i)
-			if err := copyStruct(elemField, elemPtr, field.ElemType); err != nil {
-				return err
+			if err := s.copyStruct(elemField, elemPtr, elemMeta); err != nil {
+				return withFieldPath(fmt.Sprintf("[%d]", i), err)
 			}
 		}
 		return nil
@@ -253,7 +538,7 @@ func copyArray(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) err
 // copySlice copies a slice field.
 // Note: This assumes the C struct has a slice-like structure with pointer + length.
 // The exact layout depends on how slices are represented in C.
-func copySlice(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) error {
+func (s *copyState) copySlice(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) error {
 	// For now, we'll assume C slices are represented as:
 	// struct { void* data; size_t len; }
 	// This is a common pattern in C for representing Go slices.
@@ -270,9 +555,21 @@ func copySlice(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) err
 		return nil
 	}
 
+	if slice.len < 0 {
+		return fmt.Errorf("%w: negative length %d", ErrLimitExceeded, slice.len)
+	}
+	if err := s.checkElements(uint64(slice.len)); err != nil {
+		return err
+	}
+	elemSize := field.ElemType.Size()
+	if err := s.allocateElements(uint64(slice.len), elemSize); err != nil {
+		return err
+	}
+
+	s.read += slice.len * int(cElemSize(field.ElemType))
+
 	// Create a new Go slice
 	newSlice := reflect.MakeSlice(field.ReflectType, slice.len, slice.len)
-	elemSize := field.ElemType.Size()
 	elemKind := field.ElemType.Kind()
 
 	// Copy elements based on type
@@ -285,10 +582,12 @@ func copySlice(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) err
 			}
 		}
 	} else if elemKind == reflect.String {
+		// The C elements are char*, not Go string headers
+		stride := cElemSize(field.ElemType)
 		for i := 0; i < slice.len; i++ {
-			elemPtr := unsafe.Pointer(uintptr(slice.data) + uintptr(i)*elemSize)
+			elemPtr := unsafe.Pointer(uintptr(slice.data) + uintptr(i)*stride)
 			elemField := newSlice.Index(i)
-			if err := copyString(elemField, elemPtr); err != nil {
+			if err := s.copyString(elemField, elemPtr); err != nil {
 				return err
 			}
 		}
@@ -301,30 +600,39 @@ func copySlice(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) err
 }
 
 // copyPointer copies a pointer field.
-func copyPointer(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) error {
+func (s *copyState) copyPointer(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) error {
 	// Read the pointer value from C
 	ptrValue := *(*unsafe.Pointer)(cPtr)
-	
+
 	if ptrValue == nil {
-		goField.Set(reflect.Zero(field.ReflectType))
-		return nil
+		return copyNull(goField, field)
 	}
 
 	// Create a new instance of the pointed-to type
 	elemType := field.ElemType
+	if err := s.allocate(elemType.Size()); err != nil {
+		return err
+	}
 	newElem := reflect.New(elemType)
 
 	// If it's a primitive or string, copy directly
 	if isPrimitiveKind(elemType.Kind()) {
+		s.read += int(elemType.Size())
 		if err := copyPrimitive(newElem.Elem(), ptrValue, elemType); err != nil {
 			return err
 		}
 	} else if elemType.Kind() == reflect.String {
-		if err := copyString(newElem.Elem(), ptrValue); err != nil {
+		// A *string field maps to a char*: the pointer read above is the
+		// string itself, so decode from the field rather than its target
+		if err := s.copyString(newElem.Elem(), cPtr); err != nil {
 			return err
 		}
 	} else if elemType.Kind() == reflect.Struct {
-		if err := copyStruct(newElem.Elem(), ptrValue, elemType); err != nil {
+		elemMeta := s.nestedMetadata(field, elemType)
+		if elemMeta != nil {
+			s.read += int(elemMeta.Size)
+		}
+		if err := s.copyStruct(newElem.Elem(), ptrValue, elemMeta); err != nil {
 			return err
 		}
 	} else {
@@ -334,3 +642,105 @@ func copyPointer(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) e
 	goField.Set(newElem)
 	return nil
 }
+
+// copyOpaque copies a raw C pointer value (void*, function pointer, handle)
+// into an unsafe.Pointer or uintptr field without dereferencing it.
+func copyOpaque(goField reflect.Value, cPtr unsafe.Pointer, fieldType reflect.Type) error {
+	switch fieldType.Kind() {
+	case reflect.UnsafePointer:
+		goField.SetPointer(*(*unsafe.Pointer)(cPtr))
+	case reflect.Uintptr:
+		goField.SetUint(uint64(*(*uintptr)(cPtr)))
+	default:
+		return ErrUnsupportedType
+	}
+	return nil
+}
+
+// copyMap fills a map field from C key/value arrays described by field.Map.
+// cPtr points at the keys (or pairs) pointer; the values pointer and entry
+// count are located relative to the start of the enclosing C struct.
+func (s *copyState) copyMap(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) error {
+	layout := field.Map
+	if layout == nil {
+		return ErrUnsupportedType
+	}
+
+	structPtr := unsafe.Pointer(uintptr(cPtr) - layout.KeysOffset)
+	n := readCLength(unsafe.Pointer(uintptr(structPtr)+layout.LenOffset), layout.LenSize)
+
+	keysPtr := *(*unsafe.Pointer)(cPtr)
+	valuesPtr := keysPtr
+	if layout.Paired {
+		valuesPtr = *(*unsafe.Pointer)(unsafe.Pointer(uintptr(structPtr) + layout.ValuesOffset))
+	} else if keysPtr != nil {
+		valuesPtr = unsafe.Pointer(uintptr(keysPtr) + layout.ValueOffset)
+	}
+
+	if n == 0 || keysPtr == nil || valuesPtr == nil {
+		goField.Set(reflect.MakeMap(field.ReflectType))
+		return nil
+	}
+
+	if err := s.checkElements(n); err != nil {
+		return err
+	}
+	if err := s.allocateElements(n, field.KeyType.Size()+field.ElemType.Size()); err != nil {
+		return err
+	}
+	s.read += int(n) * int(cElemSize(field.KeyType)+cElemSize(field.ElemType))
+
+	result := reflect.MakeMapWithSize(field.ReflectType, int(n))
+	for i := uintptr(0); i < uintptr(n); i++ {
+		key := reflect.New(field.KeyType).Elem()
+		if err := s.copyMapElem(key, unsafe.Pointer(uintptr(keysPtr)+i*layout.KeyStride)); err != nil {
+			return err
+		}
+
+		if !layout.LastWins && result.MapIndex(key).IsValid() {
+			return fmt.Errorf("%w: %v", ErrDuplicateKey, key.Interface())
+		}
+
+		value := reflect.New(field.ElemType).Elem()
+		if err := s.copyMapElem(value, unsafe.Pointer(uintptr(valuesPtr)+i*layout.ValueStride)); err != nil {
+			return err
+		}
+
+		result.SetMapIndex(key, value)
+	}
+
+	goField.Set(result)
+	return nil
+}
+
+// cElemSize returns the C size of a primitive or string slice or map
+// element: a string is read through a char*.
+func cElemSize(t reflect.Type) uintptr {
+	if t.Kind() == reflect.String {
+		return unsafe.Sizeof(uintptr(0))
+	}
+	return t.Size()
+}
+
+// copyMapElem copies a single map key or value (primitive or char*).
+func (s *copyState) copyMapElem(goValue reflect.Value, cPtr unsafe.Pointer) error {
+	if goValue.Kind() == reflect.String {
+		return s.copyString(goValue, cPtr)
+	}
+	return copyPrimitive(goValue, cPtr, goValue.Type())
+}
+
+// readCLength reads an unsigned C integer (size_t, uint32_t, ...) of the
+// given size.
+func readCLength(cPtr unsafe.Pointer, size uintptr) uint64 {
+	switch size {
+	case 1:
+		return uint64(*(*uint8)(cPtr))
+	case 2:
+		return uint64(*(*uint16)(cPtr))
+	case 4:
+		return uint64(*(*uint32)(cPtr))
+	default:
+		return *(*uint64)(cPtr)
+	}
+}
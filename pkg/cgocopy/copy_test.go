@@ -2,7 +2,9 @@ package cgocopy2
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"unsafe"
 )
@@ -47,6 +49,40 @@ func cString(s string) *byte {
 	return &bytes[0]
 }
 
+// mirrorCInfo describes the C struct called name whose layout the Go struct
+// M mirrors. Each spec gives the C name and type ("level uint32") of the M
+// field at the same position; offsets and sizes come from M, and pointer
+// fields are marked IsPointer.
+func mirrorCInfo[M any](name string, specs ...string) CStructInfo {
+	mirror := reflect.TypeFor[M]()
+	if len(specs) != mirror.NumField() {
+		panic(fmt.Sprintf("mirrorCInfo: %d specs for the %d fields of %s", len(specs), mirror.NumField(), mirror))
+	}
+
+	info := CStructInfo{Name: name, Size: mirror.Size()}
+	for i, spec := range specs {
+		cName, cType, _ := strings.Cut(spec, " ")
+		field := mirror.Field(i)
+		kind := field.Type.Kind()
+		info.Fields = append(info.Fields, CFieldInfo{
+			Name:      cName,
+			Type:      cType,
+			Offset:    field.Offset,
+			Size:      field.Type.Size(),
+			IsPointer: kind == reflect.Ptr || kind == reflect.UnsafePointer,
+		})
+	}
+	return info
+}
+
+// mustPrecompile fails the test if registering a fixture type failed.
+func mustPrecompile(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+}
+
 func TestCopy_Simple(t *testing.T) {
 	Reset()
 	defer Reset()
@@ -339,6 +375,381 @@ func TestCopy_TaggedStruct(t *testing.T) {
 	}
 }
 
+func TestCopy_OpaqueFields(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type CHandles struct {
+		UserData unsafe.Pointer
+		Callback uintptr
+	}
+
+	type Handles struct {
+		UserData unsafe.Pointer
+		Callback uintptr
+	}
+
+	if err := Precompile[Handles](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	target := 7
+	cStruct := CHandles{
+		UserData: unsafe.Pointer(&target),
+		Callback: 0xdeadbeef,
+	}
+
+	result, err := Copy[Handles](unsafe.Pointer(&cStruct))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	if result.UserData != unsafe.Pointer(&target) {
+		t.Errorf("UserData = %p, want %p", result.UserData, &target)
+	}
+	if result.Callback != 0xdeadbeef {
+		t.Errorf("Callback = %#x, want 0xdeadbeef", result.Callback)
+	}
+}
+
+// cKeyValueConfig simulates `const char** keys; const char** values; size_t n;`.
+type cKeyValueConfig struct {
+	Keys   unsafe.Pointer
+	Values unsafe.Pointer
+	N      uint64
+}
+
+func keyValueConfigInfo() CStructInfo {
+	var c cKeyValueConfig
+	return CStructInfo{
+		Name: "KeyValueConfig",
+		Size: unsafe.Sizeof(c),
+		Fields: []CFieldInfo{
+			{Name: "keys", Type: "pointer", Offset: unsafe.Offsetof(c.Keys), Size: unsafe.Sizeof(c.Keys), IsPointer: true},
+			{Name: "values", Type: "pointer", Offset: unsafe.Offsetof(c.Values), Size: unsafe.Sizeof(c.Values), IsPointer: true},
+			{Name: "n", Type: "uint64", Offset: unsafe.Offsetof(c.N), Size: unsafe.Sizeof(c.N)},
+		},
+	}
+}
+
+func TestCopy_MapPairedArrays(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Config struct {
+		Options map[string]string `cgocopy:"keys,values=values,len=n"`
+	}
+
+	if err := PrecompileWithC[Config](keyValueConfigInfo()); err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+
+	keys := []*byte{cString("host"), cString("port")}
+	values := []*byte{cString("localhost"), cString("8080")}
+	cStruct := cKeyValueConfig{
+		Keys:   unsafe.Pointer(&keys[0]),
+		Values: unsafe.Pointer(&values[0]),
+		N:      2,
+	}
+
+	result, err := Copy[Config](unsafe.Pointer(&cStruct))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	want := map[string]string{"host": "localhost", "port": "8080"}
+	if !reflect.DeepEqual(result.Options, want) {
+		t.Errorf("Options = %v, want %v", result.Options, want)
+	}
+}
+
+func TestCopy_MapUntaggedFieldAfterMap(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	// Map entry fields are not matched positionally: Port binds to port,
+	// not to the values or n field of the map
+	type cServer struct {
+		Keys   unsafe.Pointer
+		Values unsafe.Pointer
+		N      uint64
+		Port   int32
+	}
+	type Server struct {
+		Options map[string]string `cgocopy:",values=values,len=n"`
+		Port    int32
+	}
+
+	var c cServer
+	err := PrecompileWithC[Server](CStructInfo{
+		Name: "Server",
+		Size: unsafe.Sizeof(c),
+		Fields: []CFieldInfo{
+			{Name: "keys", Type: "pointer", Offset: unsafe.Offsetof(c.Keys), Size: unsafe.Sizeof(c.Keys), IsPointer: true},
+			{Name: "values", Type: "pointer", Offset: unsafe.Offsetof(c.Values), Size: unsafe.Sizeof(c.Values), IsPointer: true},
+			{Name: "n", Type: "uint64", Offset: unsafe.Offsetof(c.N), Size: unsafe.Sizeof(c.N)},
+			{Name: "port", Type: "int32", Offset: unsafe.Offsetof(c.Port), Size: unsafe.Sizeof(c.Port)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+
+	metadata := GetMetadata[Server]()
+	if got := metadata.Fields[1].CName; got != "port" {
+		t.Fatalf("Port bound to C field %q, want port", got)
+	}
+
+	keys := []*byte{cString("host")}
+	values := []*byte{cString("localhost")}
+	c = cServer{Keys: unsafe.Pointer(&keys[0]), Values: unsafe.Pointer(&values[0]), N: 1, Port: 8080}
+
+	result, err := Copy[Server](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if result.Port != 8080 {
+		t.Errorf("Port = %d, want 8080", result.Port)
+	}
+	if want := map[string]string{"host": "localhost"}; !reflect.DeepEqual(result.Options, want) {
+		t.Errorf("Options = %v, want %v", result.Options, want)
+	}
+}
+
+func TestCopy_MapPairStructs(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type cPair struct {
+		Key   unsafe.Pointer
+		Value int32
+	}
+	type cLimits struct {
+		Pairs unsafe.Pointer
+		Count uint32
+	}
+	type Limits struct {
+		Values map[string]int32 `cgocopy:"pairs,len=count"`
+	}
+
+	var c cLimits
+	err := PrecompileWithC[Limits](CStructInfo{
+		Name: "Limits",
+		Size: unsafe.Sizeof(c),
+		Fields: []CFieldInfo{
+			{Name: "pairs", Type: "pointer", Offset: unsafe.Offsetof(c.Pairs), Size: unsafe.Sizeof(c.Pairs), IsPointer: true},
+			{Name: "count", Type: "uint32", Offset: unsafe.Offsetof(c.Count), Size: unsafe.Sizeof(c.Count)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+
+	pairs := []cPair{
+		{Key: unsafe.Pointer(cString("cpu")), Value: 4},
+		{Key: unsafe.Pointer(cString("mem")), Value: 512},
+	}
+	c = cLimits{Pairs: unsafe.Pointer(&pairs[0]), Count: 2}
+
+	result, err := Copy[Limits](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	want := map[string]int32{"cpu": 4, "mem": 512}
+	if !reflect.DeepEqual(result.Values, want) {
+		t.Errorf("Values = %v, want %v", result.Values, want)
+	}
+}
+
+func TestCopy_MapDuplicateKeys(t *testing.T) {
+	keys := []*byte{cString("mode"), cString("mode")}
+	values := []*byte{cString("fast"), cString("safe")}
+	cStruct := cKeyValueConfig{
+		Keys:   unsafe.Pointer(&keys[0]),
+		Values: unsafe.Pointer(&values[0]),
+		N:      2,
+	}
+
+	t.Run("error", func(t *testing.T) {
+		Reset()
+		defer Reset()
+
+		type Config struct {
+			Options map[string]string `cgocopy:"keys,values=values,len=n"`
+		}
+		if err := PrecompileWithC[Config](keyValueConfigInfo()); err != nil {
+			t.Fatalf("PrecompileWithC() error = %v", err)
+		}
+
+		_, err := Copy[Config](unsafe.Pointer(&cStruct))
+		if !errors.Is(err, ErrDuplicateKey) {
+			t.Errorf("Copy() error = %v, want ErrDuplicateKey", err)
+		}
+	})
+
+	t.Run("last wins", func(t *testing.T) {
+		Reset()
+		defer Reset()
+
+		type Config struct {
+			Options map[string]string `cgocopy:"keys,values=values,len=n,dup=last"`
+		}
+		if err := PrecompileWithC[Config](keyValueConfigInfo()); err != nil {
+			t.Fatalf("PrecompileWithC() error = %v", err)
+		}
+
+		result, err := Copy[Config](unsafe.Pointer(&cStruct))
+		if err != nil {
+			t.Fatalf("Copy() error = %v", err)
+		}
+		if result.Options["mode"] != "safe" {
+			t.Errorf("Options[mode] = %q, want %q", result.Options["mode"], "safe")
+		}
+	})
+}
+
+type EventHeader struct {
+	Kind      int32
+	Timestamp int64
+}
+
+type eventSource struct {
+	Source uint16
+}
+
+func TestCopy_EmbeddedStructFlattened(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Event struct {
+		EventHeader
+		eventSource
+		Payload int32
+	}
+
+	if err := Precompile[Event](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	metadata := GetMetadata[Event]()
+	if len(metadata.Fields) != 4 {
+		t.Fatalf("len(Fields) = %d, want 4 (embedded fields flattened)", len(metadata.Fields))
+	}
+	if metadata.HasNestedStructs {
+		t.Error("HasNestedStructs = true, want false for flattened embedded structs")
+	}
+
+	cStruct := Event{
+		EventHeader: EventHeader{Kind: 3, Timestamp: 1700000000},
+		eventSource: eventSource{Source: 9},
+		Payload:     42,
+	}
+
+	result, err := Copy[Event](unsafe.Pointer(&cStruct))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if result != cStruct {
+		t.Errorf("Copy() = %+v, want %+v", result, cStruct)
+	}
+}
+
+func TestCopy_EmbeddedStructMatchedByCName(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	// C: struct { int32_t payload; int32_t kind; int64_t timestamp; int32_t extra; }
+	type cEvent struct {
+		Payload   int32
+		Kind      int32
+		Timestamp int64
+		Extra     int32
+	}
+
+	type Event struct {
+		Payload int32
+		EventHeader
+		Extra int32
+	}
+
+	var c cEvent
+	err := PrecompileWithC[Event](CStructInfo{
+		Name: "Event",
+		Size: unsafe.Sizeof(c),
+		Fields: []CFieldInfo{
+			{Name: "payload", Type: "int32", Offset: unsafe.Offsetof(c.Payload), Size: 4},
+			{Name: "kind", Type: "int32", Offset: unsafe.Offsetof(c.Kind), Size: 4},
+			{Name: "timestamp", Type: "int64", Offset: unsafe.Offsetof(c.Timestamp), Size: 8},
+			{Name: "extra", Type: "int32", Offset: unsafe.Offsetof(c.Extra), Size: 4},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+
+	c = cEvent{Payload: 1, Kind: 2, Timestamp: 3, Extra: 4}
+	result, err := Copy[Event](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	want := Event{Payload: 1, EventHeader: EventHeader{Kind: 2, Timestamp: 3}, Extra: 4}
+	if result != want {
+		t.Errorf("Copy() = %+v, want %+v", result, want)
+	}
+}
+
+func TestCopy_FlattenTagOption(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Event struct {
+		Header  EventHeader `cgocopy:",flatten"`
+		Payload int32
+	}
+
+	if err := Precompile[Event](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	metadata := GetMetadata[Event]()
+	if len(metadata.Fields) != 3 {
+		t.Fatalf("len(Fields) = %d, want 3", len(metadata.Fields))
+	}
+	if !reflect.DeepEqual(metadata.Fields[1].IndexPath, []int{0, 1}) {
+		t.Errorf("Timestamp IndexPath = %v, want [0 1]", metadata.Fields[1].IndexPath)
+	}
+
+	cStruct := Event{Header: EventHeader{Kind: 5, Timestamp: 6}, Payload: 7}
+	result, err := Copy[Event](unsafe.Pointer(&cStruct))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if result != cStruct {
+		t.Errorf("Copy() = %+v, want %+v", result, cStruct)
+	}
+}
+
+func TestPrecompile_EmbeddedStructWithCNameIsNested(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Event struct {
+		EventHeader `cgocopy:"header"`
+		Payload     int32
+	}
+
+	if err := Precompile[Event](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	metadata := GetMetadata[Event]()
+	if len(metadata.Fields) != 2 || metadata.Fields[0].Type != FieldTypeStruct {
+		t.Errorf("Fields = %+v, want nested EventHeader struct field", metadata.Fields)
+	}
+}
+
 func TestCopyPrimitive(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -439,7 +850,7 @@ func TestCopyString_ValidString(t *testing.T) {
 	resultValue := reflect.ValueOf(&result).Elem()
 
 	// Copy the string
-	err := copyString(resultValue, unsafe.Pointer(&cStr))
+	err := newCopyState(globalRegistry, Limits{}).copyString(resultValue, unsafe.Pointer(&cStr))
 	if err != nil {
 		t.Fatalf("copyString() error = %v", err)
 	}
@@ -455,7 +866,7 @@ func TestCopyString_EmptyString(t *testing.T) {
 	var result string
 	resultValue := reflect.ValueOf(&result).Elem()
 
-	err := copyString(resultValue, unsafe.Pointer(&cStr))
+	err := newCopyState(globalRegistry, Limits{}).copyString(resultValue, unsafe.Pointer(&cStr))
 	if err != nil {
 		t.Fatalf("copyString() error = %v", err)
 	}
@@ -471,7 +882,7 @@ func TestCopyString_NilPointer(t *testing.T) {
 	var result string
 	resultValue := reflect.ValueOf(&result).Elem()
 
-	err := copyString(resultValue, unsafe.Pointer(&cStr))
+	err := newCopyState(globalRegistry, Limits{}).copyString(resultValue, unsafe.Pointer(&cStr))
 	if err != nil {
 		t.Fatalf("copyString() error = %v", err)
 	}
@@ -480,3 +891,65 @@ func TestCopyString_NilPointer(t *testing.T) {
 		t.Errorf("result = %q, want empty string for nil pointer", result)
 	}
 }
+
+func TestCopyByCName(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := PrecompileWithC[CopySimple](CStructInfo{
+		Name: "copy_simple",
+		Size: unsafe.Sizeof(CopySimple{}),
+		Fields: []CFieldInfo{
+			{Name: "ID", Type: "int64", Offset: unsafe.Offsetof(CopySimple{}.ID), Size: 8},
+			{Name: "Count", Type: "uint32", Offset: unsafe.Offsetof(CopySimple{}.Count), Size: 4},
+			{Name: "Score", Type: "float64", Offset: unsafe.Offsetof(CopySimple{}.Score), Size: 8},
+			{Name: "Flag", Type: "bool", Offset: unsafe.Offsetof(CopySimple{}.Flag), Size: 1},
+		},
+	}); err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+
+	cStruct := CopySimple{ID: 7, Count: 3, Score: 1.5, Flag: true}
+	got, err := CopyByCName("copy_simple", unsafe.Pointer(&cStruct))
+	if err != nil {
+		t.Fatalf("CopyByCName() error = %v", err)
+	}
+
+	ptr, ok := got.(*CopySimple)
+	if !ok {
+		t.Fatalf("CopyByCName() returned %T, want *CopySimple", got)
+	}
+	if *ptr != cStruct {
+		t.Errorf("CopyByCName() = %+v, want %+v", *ptr, cStruct)
+	}
+
+	// Each call returns a fresh value
+	again, _ := CopyByCName("copy_simple", unsafe.Pointer(&cStruct))
+	if again.(*CopySimple) == ptr {
+		t.Error("CopyByCName() returned the same pointer twice")
+	}
+}
+
+func TestCopyByCName_Errors(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[CopySimple](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	cStruct := CopySimple{ID: 1}
+	if _, err := CopyByCName("unknown_t", unsafe.Pointer(&cStruct)); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("CopyByCName(unknown) error = %v, want ErrNotRegistered", err)
+	}
+
+	name := GetMetadata[CopySimple]().CTypeName
+	if _, err := CopyByCName(name, nil); !errors.Is(err, ErrNilPointer) {
+		t.Errorf("CopyByCName(nil) error = %v, want ErrNilPointer", err)
+	}
+
+	// Scoped registries do not see global registrations
+	if _, err := NewRegistry().CopyByCName(name, unsafe.Pointer(&cStruct)); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Registry.CopyByCName() error = %v, want ErrNotRegistered", err)
+	}
+}
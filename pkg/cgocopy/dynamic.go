@@ -0,0 +1,255 @@
+package cgocopy2
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// CopyToMap decodes the C struct at cPtr using only its C metadata, with no
+// Go struct type. Nested struct types are resolved in the global registry.
+// See Registry.CopyToMap.
+func CopyToMap(cInfo CStructInfo, cPtr unsafe.Pointer) (map[string]any, error) {
+	return globalRegistry.CopyToMap(cInfo, cPtr)
+}
+
+// CopyToMap decodes the C struct at cPtr into a map keyed by C field name,
+// using only cInfo. It is meant for debugging tools and generic bridges that
+// have no Go struct for the C type.
+//
+// Value types are derived from the C type names the macros emit:
+//   - "bool" and the integer and float names become the Go type of the same
+//     kind and size (e.g. "int32" becomes int32, "double" float64)
+//   - "string" (char*) becomes a Go string; a NULL pointer becomes ""
+//   - char array fields ("char[]", which the macros emit for inline char
+//     arrays) become the text up to their first NUL, as does a "string"
+//     field that is not pointer-sized
+//   - "pointer" and other pointer types become the raw unsafe.Pointer
+//   - other array fields ("int[]", "Point[]") become []any of their elements
+//   - any other name is a nested struct and becomes a nested map
+//
+// Nested struct metadata is looked up by C type name in r's C metadata
+// catalog (AddCMetadata) and then among the registered types, so nested
+// struct fields must carry their C type name (CGOCOPY_STRUCT_FIELD). Fields
+// whose type is the generic "struct" that CGOCOPY_FIELD emits cannot be
+// resolved and fail with ErrMetadataNotFound. The global default limits
+// apply.
+//
+// Example:
+//
+//	fields, err := cgocopy2.CopyToMap(extractCMetadata(C.get_User_metadata()), cPtr)
+//	if err != nil {
+//	    return err
+//	}
+//	fmt.Println(fields["username"])
+func (r *Registry) CopyToMap(cInfo CStructInfo, cPtr unsafe.Pointer) (map[string]any, error) {
+	if cPtr == nil {
+		return nil, ErrNilPointer
+	}
+
+	state := newCopyState(r, DefaultLimits())
+	if debugLogging.enabled.Load() {
+		state.logging = true
+		state.logger = taggedLogger(debugLogging.global.Load(), cInfo.Name)
+	}
+
+	region := traceRegion(context.Background(), "CopyToMap", cInfo.Name)
+	result, err := state.decodeCStruct(&cInfo, cPtr)
+	endTraceRegion(region)
+	if err != nil {
+		return nil, fmt.Errorf("copy failed for C type %s: %w", cInfo.Name, err)
+	}
+	return result, nil
+}
+
+// decodeCStruct decodes every field of the C struct at cPtr into a map.
+func (s *copyState) decodeCStruct(cInfo *CStructInfo, cPtr unsafe.Pointer) (map[string]any, error) {
+	result := make(map[string]any, len(cInfo.Fields))
+	for i := range cInfo.Fields {
+		cField := &cInfo.Fields[i]
+		value, err := s.decodeLoggedCField(cField, unsafe.Pointer(uintptr(cPtr)+cField.Offset))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", cField.Name, err)
+		}
+		result[cField.Name] = value
+	}
+	return result, nil
+}
+
+// decodeLoggedCField decodes a single C field, and logs it when debug
+// logging is enabled. Nested structs log their own fields under the
+// extended path.
+func (s *copyState) decodeLoggedCField(cField *CFieldInfo, cPtr unsafe.Pointer) (any, error) {
+	if !s.logging {
+		return s.decodeCField(cField, cPtr)
+	}
+
+	s.path = append(s.path, cField.Name)
+	defer func() { s.path = s.path[:len(s.path)-1] }()
+
+	value, err := s.decodeCField(cField, cPtr)
+	if err != nil {
+		return nil, err
+	}
+	if _, nested := value.(map[string]any); !nested {
+		s.logField("cgocopy: copied field", strings.Join(s.path, "."), cField.Offset, cField.Type,
+			reflect.ValueOf(value))
+	}
+	return value, nil
+}
+
+// decodeCField decodes a single C field, which may be an array.
+func (s *copyState) decodeCField(cField *CFieldInfo, cPtr unsafe.Pointer) (any, error) {
+	if !cField.IsArray || cField.ArrayLen <= 0 {
+		return s.decodeCValue(cField.Type, cField.Size, cField.IsPointer, cPtr)
+	}
+
+	elemName := strings.TrimSpace(strings.TrimSuffix(cField.Type, "[]"))
+	switch elemName {
+	case "char", "string":
+		return s.decodeCCharArray(cField.Size, cPtr)
+	}
+	elemSize := cField.Size / uintptr(cField.ArrayLen)
+	if err := s.checkElements(uint64(cField.ArrayLen)); err != nil {
+		return nil, err
+	}
+
+	elems := make([]any, cField.ArrayLen)
+	for i := range elems {
+		value, err := s.decodeCValue(elemName, elemSize, false, unsafe.Pointer(uintptr(cPtr)+uintptr(i)*elemSize))
+		if err != nil {
+			return nil, &ElementError{Index: i, Cause: err}
+		}
+		elems[i] = value
+	}
+	return elems, nil
+}
+
+// decodeCValue decodes one C value of the named type and size.
+func (s *copyState) decodeCValue(cTypeName string, size uintptr, isPointer bool, cPtr unsafe.Pointer) (any, error) {
+	name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cTypeName), "const "))
+
+	switch name {
+	case "string", "char*", "char *":
+		if size != unsafe.Sizeof(uintptr(0)) {
+			// Not a char*: inline text from metadata without the array flag
+			return s.decodeCCharArray(size, cPtr)
+		}
+		value := reflect.New(reflect.TypeFor[string]()).Elem()
+		if err := s.copyString(value, cPtr); err != nil {
+			return nil, err
+		}
+		return value.String(), nil
+	case "pointer":
+		return *(*unsafe.Pointer)(cPtr), nil
+	}
+
+	if isPointer || strings.HasSuffix(name, "*") {
+		return *(*unsafe.Pointer)(cPtr), nil
+	}
+
+	if kind, ok := cScalarKind(name); ok {
+		return decodeCScalar(kind, size, cPtr)
+	}
+
+	return s.decodeNestedCStruct(strings.TrimPrefix(name, "struct "), cPtr)
+}
+
+// decodeCCharArray decodes an inline char array of size bytes, stopping at
+// the first NUL.
+func (s *copyState) decodeCCharArray(size uintptr, cPtr unsafe.Pointer) (any, error) {
+	bytes := unsafe.Slice((*byte)(cPtr), size)
+	length := 0
+	for length < len(bytes) && bytes[length] != 0 {
+		length++
+	}
+	if s.limits.MaxStringLen > 0 && length > s.limits.MaxStringLen {
+		return nil, fmt.Errorf("%w: string longer than MaxStringLen %d", ErrLimitExceeded, s.limits.MaxStringLen)
+	}
+	if err := s.allocate(uintptr(length)); err != nil {
+		return nil, err
+	}
+	s.strings++
+	return string(bytes[:length]), nil
+}
+
+// decodeNestedCStruct decodes a nested struct by its C type name.
+func (s *copyState) decodeNestedCStruct(cTypeName string, cPtr unsafe.Pointer) (any, error) {
+	nested, ok := s.registry.lookupCMetadata(cTypeName)
+	if !ok {
+		metadata := s.registry.GetByCName(cTypeName)
+		if metadata == nil || metadata.CInfo == nil {
+			return nil, fmt.Errorf("%w: C type %q", ErrMetadataNotFound, cTypeName)
+		}
+		nested = *metadata.CInfo
+	}
+
+	defer s.leave()
+	if err := s.enter(nil); err != nil {
+		return nil, err
+	}
+	return s.decodeCStruct(&nested, cPtr)
+}
+
+// cScalarKind maps a C scalar type name, either as emitted by the macros
+// ("int32", "float64") or as written in C ("int", "double", "uint8_t"), to
+// the Go kind family used to decode it. Integer widths come from the field
+// size, so platform-dependent names such as "long" decode correctly.
+func cScalarKind(name string) (reflect.Kind, bool) {
+	switch name {
+	case "bool", "_Bool":
+		return reflect.Bool, true
+	case "int8", "int16", "int32", "int64",
+		"int8_t", "int16_t", "int32_t", "int64_t", "intptr_t", "ssize_t",
+		"char", "signed char", "short", "int", "long", "long long":
+		return reflect.Int, true
+	case "uint8", "uint16", "uint32", "uint64",
+		"uint8_t", "uint16_t", "uint32_t", "uint64_t", "uintptr_t", "size_t",
+		"unsigned char", "unsigned short", "unsigned int", "unsigned", "unsigned long", "unsigned long long":
+		return reflect.Uint, true
+	case "float32", "float64", "float", "double":
+		return reflect.Float64, true
+	}
+	return reflect.Invalid, false
+}
+
+// decodeCScalar reads a scalar of the given kind family and size, returning
+// the Go type of the same kind and size (e.g. int16, uint64, float32).
+func decodeCScalar(kind reflect.Kind, size uintptr, cPtr unsafe.Pointer) (any, error) {
+	switch kind {
+	case reflect.Bool:
+		return *(*uint8)(cPtr) != 0, nil
+	case reflect.Int:
+		switch size {
+		case 1:
+			return *(*int8)(cPtr), nil
+		case 2:
+			return *(*int16)(cPtr), nil
+		case 4:
+			return *(*int32)(cPtr), nil
+		case 8:
+			return *(*int64)(cPtr), nil
+		}
+	case reflect.Uint:
+		switch size {
+		case 1:
+			return *(*uint8)(cPtr), nil
+		case 2:
+			return *(*uint16)(cPtr), nil
+		case 4:
+			return *(*uint32)(cPtr), nil
+		case 8:
+			return *(*uint64)(cPtr), nil
+		}
+	case reflect.Float64:
+		switch size {
+		case 4:
+			return *(*float32)(cPtr), nil
+		case 8:
+			return *(*float64)(cPtr), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d-byte %v", ErrUnsupportedType, size, kind)
+}
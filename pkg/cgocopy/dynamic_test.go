@@ -0,0 +1,208 @@
+package cgocopy2
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type dynPoint struct {
+	X float64
+	Y float64
+}
+
+type dynRecord struct {
+	ID     int32
+	Flags  uint8
+	Active bool
+	Name   *byte
+	Tag    [16]byte
+	Scores [3]float32
+	Origin dynPoint
+	Path   [2]dynPoint
+	Handle unsafe.Pointer
+	Count  int64
+}
+
+var dynPointInfo = CStructInfo{
+	Name: "dyn_point",
+	Size: unsafe.Sizeof(dynPoint{}),
+	Fields: []CFieldInfo{
+		{Name: "x", Type: "float64", Offset: 0, Size: 8},
+		{Name: "y", Type: "double", Offset: 8, Size: 8},
+	},
+}
+
+func dynRecordInfo() CStructInfo {
+	var r dynRecord
+	return CStructInfo{
+		Name: "dyn_record",
+		Size: unsafe.Sizeof(r),
+		Fields: []CFieldInfo{
+			{Name: "id", Type: "int32", Offset: unsafe.Offsetof(r.ID), Size: 4},
+			{Name: "flags", Type: "uint8", Offset: unsafe.Offsetof(r.Flags), Size: 1},
+			{Name: "active", Type: "bool", Offset: unsafe.Offsetof(r.Active), Size: 1},
+			{Name: "name", Type: "string", Offset: unsafe.Offsetof(r.Name), Size: 8, IsPointer: true},
+			{Name: "tag", Type: "string", Offset: unsafe.Offsetof(r.Tag), Size: 16},
+			{Name: "scores", Type: "float[]", Offset: unsafe.Offsetof(r.Scores), Size: 12, IsArray: true, ArrayLen: 3},
+			{Name: "origin", Type: "dyn_point", Offset: unsafe.Offsetof(r.Origin), Size: 16},
+			{Name: "path", Type: "dyn_point[]", Offset: unsafe.Offsetof(r.Path), Size: 32, IsArray: true, ArrayLen: 2},
+			{Name: "handle", Type: "pointer", Offset: unsafe.Offsetof(r.Handle), Size: 8, IsPointer: true},
+			{Name: "count", Type: "long", Offset: unsafe.Offsetof(r.Count), Size: 8},
+		},
+	}
+}
+
+func TestCopyToMap(t *testing.T) {
+	Reset()
+	defer Reset()
+	AddCMetadata(dynPointInfo)
+
+	handle := new(int)
+	c := dynRecord{
+		ID:     42,
+		Flags:  0x81,
+		Active: true,
+		Name:   cString("alice"),
+		Tag:    [16]byte{'t', 'a', 'g'},
+		Scores: [3]float32{1.5, 2.5, 3.5},
+		Origin: dynPoint{X: 1, Y: 2},
+		Path:   [2]dynPoint{{X: 3, Y: 4}, {X: 5, Y: 6}},
+		Handle: unsafe.Pointer(handle),
+		Count:  -7,
+	}
+
+	got, err := CopyToMap(dynRecordInfo(), unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("CopyToMap() error = %v", err)
+	}
+
+	want := map[string]any{
+		"id":     int32(42),
+		"flags":  uint8(0x81),
+		"active": true,
+		"name":   "alice",
+		"tag":    "tag",
+		"scores": []any{float32(1.5), float32(2.5), float32(3.5)},
+		"origin": map[string]any{"x": float64(1), "y": float64(2)},
+		"path": []any{
+			map[string]any{"x": float64(3), "y": float64(4)},
+			map[string]any{"x": float64(5), "y": float64(6)},
+		},
+		"handle": unsafe.Pointer(handle),
+		"count":  int64(-7),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CopyToMap() =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestCopyToMap_NestedFromRegisteredType(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := PrecompileWithC[dynPoint](dynPointInfo); err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+
+	c := struct {
+		ID     int32
+		_      int32
+		Origin dynPoint
+	}{ID: 1, Origin: dynPoint{X: 7, Y: 8}}
+	info := CStructInfo{
+		Name: "holder", Size: 24,
+		Fields: []CFieldInfo{
+			{Name: "id", Type: "int", Offset: 0, Size: 4},
+			{Name: "origin", Type: "struct dyn_point", Offset: 8, Size: 16},
+		},
+	}
+
+	got, err := CopyToMap(info, unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("CopyToMap() error = %v", err)
+	}
+	if origin := got["origin"].(map[string]any); origin["x"] != float64(7) || origin["y"] != float64(8) {
+		t.Errorf("origin = %v, want map[x:7 y:8]", origin)
+	}
+}
+
+func TestCopyToMap_NULLString(t *testing.T) {
+	c := struct{ Name *byte }{}
+	info := CStructInfo{Name: "n", Size: 8, Fields: []CFieldInfo{{Name: "name", Type: "string", Size: 8}}}
+
+	got, err := CopyToMap(info, unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("CopyToMap() error = %v", err)
+	}
+	if got["name"] != "" {
+		t.Errorf("name = %q, want empty string", got["name"])
+	}
+}
+
+func TestCopyToMap_CharArrays(t *testing.T) {
+	// char tag[4]; char code[8]; as CGOCOPY_FIELD describes them
+	c := struct {
+		Tag  [4]byte
+		Code [8]byte
+	}{Tag: [4]byte{'a', 'b'}, Code: [8]byte{'c', 'o', 'd', 'e', '1', '2', '3', '4'}}
+	info := CStructInfo{Name: "codes", Size: 12, Fields: []CFieldInfo{
+		{Name: "tag", Type: "char[]", Size: 4, IsArray: true, ArrayLen: 4},
+		{Name: "code", Type: "char[]", Offset: 4, Size: 8, IsArray: true, ArrayLen: 8},
+		// Metadata without the array flag: not pointer-sized, so not a char*
+		{Name: "legacy", Type: "string", Size: 4},
+	}}
+
+	got, err := CopyToMap(info, unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("CopyToMap() error = %v", err)
+	}
+	want := map[string]any{"tag": "ab", "code": "code1234", "legacy": "ab"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CopyToMap() = %v, want %v", got, want)
+	}
+}
+
+func TestCopyToMap_Errors(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	var c [16]byte
+	if _, err := CopyToMap(dynPointInfo, nil); !errors.Is(err, ErrNilPointer) {
+		t.Errorf("CopyToMap(nil) error = %v, want ErrNilPointer", err)
+	}
+
+	generic := CStructInfo{Name: "g", Size: 16, Fields: []CFieldInfo{{Name: "inner", Type: "struct", Size: 16}}}
+	if _, err := CopyToMap(generic, unsafe.Pointer(&c)); !errors.Is(err, ErrMetadataNotFound) {
+		t.Errorf("CopyToMap(generic struct) error = %v, want ErrMetadataNotFound", err)
+	}
+
+	odd := CStructInfo{Name: "o", Size: 3, Fields: []CFieldInfo{{Name: "v", Type: "int", Size: 3}}}
+	if _, err := CopyToMap(odd, unsafe.Pointer(&c)); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("CopyToMap(3-byte int) error = %v, want ErrUnsupportedType", err)
+	}
+
+	// Scoped registries resolve nested types in their own catalog only
+	reg := NewRegistry()
+	nested := CStructInfo{Name: "h", Size: 16, Fields: []CFieldInfo{{Name: "p", Type: "dyn_point", Size: 16}}}
+	AddCMetadata(dynPointInfo)
+	if _, err := reg.CopyToMap(nested, unsafe.Pointer(&c)); !errors.Is(err, ErrMetadataNotFound) {
+		t.Errorf("Registry.CopyToMap() error = %v, want ErrMetadataNotFound", err)
+	}
+	reg.AddCMetadata(dynPointInfo)
+	if _, err := reg.CopyToMap(nested, unsafe.Pointer(&c)); err != nil {
+		t.Errorf("Registry.CopyToMap() error = %v", err)
+	}
+}
+
+func TestCopyToMap_Limits(t *testing.T) {
+	defer SetDefaultLimits(Limits{})
+	SetDefaultLimits(Limits{MaxStringLen: 2})
+
+	c := struct{ Name *byte }{Name: cString("long")}
+	info := CStructInfo{Name: "n", Size: 8, Fields: []CFieldInfo{{Name: "name", Type: "string", Size: 8}}}
+	if _, err := CopyToMap(info, unsafe.Pointer(&c)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("CopyToMap() error = %v, want ErrLimitExceeded", err)
+	}
+}
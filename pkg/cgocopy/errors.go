@@ -32,6 +32,34 @@ var (
 
 	// ErrTagFormat is returned when a cgocopy struct tag has invalid format.
 	ErrTagFormat = errors.New("invalid cgocopy tag format")
+
+	// ErrDuplicateKey is returned when a C key/value array mapped to a Go map
+	// contains the same key twice and the field does not allow last-wins.
+	ErrDuplicateKey = errors.New("duplicate map key")
+
+	// ErrRequiredField is returned when a C pointer of a field tagged
+	// `required` is NULL.
+	ErrRequiredField = errors.New("required C pointer is NULL")
+
+	// ErrRegistrySealed is returned when registering a type in a registry
+	// after Seal has been called.
+	ErrRegistrySealed = errors.New("registry is sealed")
+
+	// ErrDuplicateCName is returned when registering a type whose C struct
+	// name is already registered for a different Go type.
+	ErrDuplicateCName = errors.New("C type name already registered")
+
+	// ErrSchemaMismatch is returned by Import for unreadable or unsupported
+	// schema documents and for layouts that differ from the document.
+	ErrSchemaMismatch = errors.New("registry schema mismatch")
+
+	// ErrReleased is returned by Lazy.Get when the value was not loaded
+	// before its C memory was released.
+	ErrReleased = errors.New("lazy value released before it was loaded")
+
+	// ErrLimitExceeded is returned when a copy exceeds one of its resource
+	// limits (string length, element count, nesting depth or byte budget).
+	ErrLimitExceeded = errors.New("copy limit exceeded")
 )
 
 // ValidationError represents a validation failure for a specific field.
@@ -111,6 +139,22 @@ func (e *CopyError) Unwrap() error {
 	return e.Cause
 }
 
+// ElementError reports a failure copying one element of a C array.
+type ElementError struct {
+	Index int
+	Cause error
+}
+
+// Error implements the error interface.
+func (e *ElementError) Error() string {
+	return fmt.Sprintf("element %d: %v", e.Index, e.Cause)
+}
+
+// Unwrap allows ElementError to be used with errors.Unwrap.
+func (e *ElementError) Unwrap() error {
+	return e.Cause
+}
+
 // newValidationError creates a new ValidationError.
 func newValidationError(typeName, fieldName string, goType reflect.Type, cType, reason string) *ValidationError {
 	return &ValidationError{
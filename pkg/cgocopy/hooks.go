@@ -0,0 +1,106 @@
+package cgocopy2
+
+import (
+	"reflect"
+	"strings"
+)
+
+// AfterCopier is implemented by registered types that need fix-ups after
+// being copied from C, such as deriving computed fields.
+//
+// Copy calls AfterCopyFromC bottom-up: nested structs, array elements and
+// pointer targets run their hooks before the struct that contains them.
+// A returned error fails the copy with a CopyError naming the field path.
+//
+// Every entry point that fills a registered struct runs the hooks: Copy,
+// the iterators and bulk copies, Projection.Copy and CopyFields (on the
+// partially copied value) and Update (after every update).
+type AfterCopier interface {
+	AfterCopyFromC() error
+}
+
+// CopyValidator is implemented by registered types that check invariants
+// after being copied from C. ValidateFromC runs after AfterCopyFromC, from
+// the same entry points.
+type CopyValidator interface {
+	ValidateFromC() error
+}
+
+var (
+	afterCopierType   = reflect.TypeOf((*AfterCopier)(nil)).Elem()
+	copyValidatorType = reflect.TypeOf((*CopyValidator)(nil)).Elem()
+)
+
+// hasCopyHooks reports whether *goType implements AfterCopier or CopyValidator.
+func hasCopyHooks(goType reflect.Type) bool {
+	ptrType := reflect.PointerTo(goType)
+	return ptrType.Implements(afterCopierType) || ptrType.Implements(copyValidatorType)
+}
+
+// runCopyHooks invokes the post-copy hooks of an addressable struct value.
+func runCopyHooks(value reflect.Value, metadata *StructMetadata) error {
+	if !metadata.HasHooks {
+		return nil
+	}
+
+	target := value.Addr().Interface()
+	if hook, ok := target.(AfterCopier); ok {
+		if err := hook.AfterCopyFromC(); err != nil {
+			return err
+		}
+	}
+	if validator, ok := target.(CopyValidator); ok {
+		if err := validator.ValidateFromC(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathError carries the path of the nested field that failed so that the
+// top-level CopyError can report it.
+type pathError struct {
+	path string
+	err  error
+}
+
+// Error implements the error interface.
+func (e *pathError) Error() string {
+	return e.path + ": " + e.err.Error()
+}
+
+// Unwrap allows pathError to be used with errors.Is and errors.As.
+func (e *pathError) Unwrap() error {
+	return e.err
+}
+
+// withFieldPath prefixes err's field path with name, a field name or an
+// element index like "[3]".
+func withFieldPath(name string, err error) error {
+	if pe, ok := err.(*pathError); ok {
+		return &pathError{path: joinFieldPath(name, pe.path), err: pe.err}
+	}
+	return &pathError{path: name, err: err}
+}
+
+// splitFieldPath separates the nested field path from the underlying cause.
+func splitFieldPath(err error) (string, error) {
+	if pe, ok := err.(*pathError); ok {
+		return pe.path, pe.err
+	}
+	return "", err
+}
+
+// joinFieldPath joins two path segments with a dot, except before an index.
+func joinFieldPath(prefix, rest string) string {
+	switch {
+	case prefix == "":
+		return rest
+	case rest == "":
+		return prefix
+	case strings.HasPrefix(rest, "["):
+		return prefix + rest
+	default:
+		return prefix + "." + rest
+	}
+}
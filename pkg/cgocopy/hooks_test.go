@@ -0,0 +1,230 @@
+package cgocopy2
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+var hookOrder []string
+
+type HookPoint struct {
+	X, Y  float64
+	Norm2 float64 `cgocopy:"-"`
+}
+
+func (p *HookPoint) AfterCopyFromC() error {
+	hookOrder = append(hookOrder, "point")
+	p.Norm2 = p.X*p.X + p.Y*p.Y
+	return nil
+}
+
+type HookShape struct {
+	Origin HookPoint
+	Corner [2]HookPoint
+	Sides  int32
+}
+
+func (s *HookShape) AfterCopyFromC() error {
+	hookOrder = append(hookOrder, "shape")
+	return nil
+}
+
+func (s *HookShape) ValidateFromC() error {
+	if s.Sides < 3 {
+		return errRange
+	}
+	return nil
+}
+
+var errRange = errors.New("sides out of range")
+
+func TestCopy_AfterCopyHooksBottomUp(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, Precompile[HookShape]())
+
+	if !GetMetadata[HookShape]().HasHooks {
+		t.Error("HasHooks = false, want true")
+	}
+
+	hookOrder = nil
+	c := HookShape{Origin: HookPoint{X: 3, Y: 4}, Corner: [2]HookPoint{{X: 1}, {Y: 2}}, Sides: 4}
+
+	result, err := Copy[HookShape](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	if result.Origin.Norm2 != 25 || result.Corner[1].Norm2 != 4 {
+		t.Errorf("derived fields not computed: %+v", result)
+	}
+
+	want := []string{"point", "point", "point", "shape"}
+	if len(hookOrder) != len(want) {
+		t.Fatalf("hook order = %v, want %v", hookOrder, want)
+	}
+	for i := range want {
+		if hookOrder[i] != want[i] {
+			t.Fatalf("hook order = %v, want %v", hookOrder, want)
+		}
+	}
+}
+
+func TestCopy_ValidationHookFails(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, Precompile[HookShape]())
+
+	c := HookShape{Sides: 2}
+	_, err := Copy[HookShape](unsafe.Pointer(&c))
+	if !errors.Is(err, errRange) {
+		t.Fatalf("Copy() error = %v, want errRange", err)
+	}
+
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) {
+		t.Errorf("error should be a CopyError, got %T", err)
+	}
+}
+
+type hookFailingPoint struct {
+	X int32
+}
+
+func (p *hookFailingPoint) ValidateFromC() error {
+	if p.X < 0 {
+		return errRange
+	}
+	return nil
+}
+
+func TestCopy_NestedHookErrorReportsPath(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Polygon struct {
+		Points [3]hookFailingPoint
+	}
+
+	mustPrecompile(t, Precompile[Polygon]())
+
+	c := Polygon{Points: [3]hookFailingPoint{{X: 1}, {X: 2}, {X: -1}}}
+	_, err := Copy[Polygon](unsafe.Pointer(&c))
+
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) {
+		t.Fatalf("Copy() error = %v, want CopyError", err)
+	}
+	if copyErr.FieldName != "Points[2]" {
+		t.Errorf("FieldName = %q, want %q", copyErr.FieldName, "Points[2]")
+	}
+	if !errors.Is(err, errRange) {
+		t.Errorf("error should wrap errRange, got %v", err)
+	}
+}
+
+func TestProjection_RunsHooks(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, Precompile[HookShape]())
+
+	p, err := NewProjection[HookShape]("Origin.X", "Origin.Y", "Sides")
+	if err != nil {
+		t.Fatalf("NewProjection() error = %v", err)
+	}
+
+	hookOrder = nil
+	c := HookShape{Origin: HookPoint{X: 3, Y: 4}, Sides: 4}
+	result, err := p.Copy(unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Projection.Copy() error = %v", err)
+	}
+	if result.Origin.Norm2 != 25 {
+		t.Errorf("Origin.Norm2 = %v, want 25", result.Origin.Norm2)
+	}
+	if len(hookOrder) != 2 || hookOrder[0] != "point" || hookOrder[1] != "shape" {
+		t.Errorf("hook order = %v, want [point shape]", hookOrder)
+	}
+
+	// The validator sees the projected value, so it cannot be bypassed
+	c.Sides = 2
+	if _, err := p.Copy(unsafe.Pointer(&c)); !errors.Is(err, errRange) {
+		t.Errorf("Projection.Copy() error = %v, want errRange", err)
+	}
+	if _, err := CopyFields[HookShape](unsafe.Pointer(&c), "Sides"); !errors.Is(err, errRange) {
+		t.Errorf("CopyFields() error = %v, want errRange", err)
+	}
+}
+
+func TestUpdate_RunsHooks(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, Precompile[HookShape]())
+
+	hookOrder = nil
+	var shape HookShape
+	c := HookShape{Origin: HookPoint{X: 3, Y: 4}, Sides: 4}
+	if _, err := Update(&shape, unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if shape.Origin.Norm2 != 25 {
+		t.Errorf("Origin.Norm2 = %v, want 25", shape.Origin.Norm2)
+	}
+	want := []string{"point", "point", "point", "shape"}
+	if len(hookOrder) != len(want) {
+		t.Errorf("hook order = %v, want %v", hookOrder, want)
+	}
+
+	// Hooks run even when nothing changed
+	c.Sides = 2
+	shape.Sides = 2
+	_, err := Update(&shape, unsafe.Pointer(&c))
+	if !errors.Is(err, errRange) {
+		t.Fatalf("Update() error = %v, want errRange", err)
+	}
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) {
+		t.Errorf("error should be a CopyError, got %T", err)
+	}
+}
+
+func TestUpdate_NestedHookErrorReportsPath(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Segment struct {
+		From, To hookFailingPoint
+	}
+	mustPrecompile(t, Precompile[Segment]())
+
+	var segment Segment
+	c := Segment{From: hookFailingPoint{X: 1}, To: hookFailingPoint{X: -1}}
+	_, err := Update(&segment, unsafe.Pointer(&c))
+
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) {
+		t.Fatalf("Update() error = %v, want CopyError", err)
+	}
+	if copyErr.FieldName != "To" || !errors.Is(err, errRange) {
+		t.Errorf("Update() error = %v, want errRange at To", err)
+	}
+}
+
+func TestJoinFieldPath(t *testing.T) {
+	tests := []struct {
+		prefix, rest, want string
+	}{
+		{"", "X", "X"},
+		{"A", "", "A"},
+		{"A", "B", "A.B"},
+		{"A", "[2]", "A[2]"},
+		{"A", "[2].B", "A[2].B"},
+	}
+
+	for _, tt := range tests {
+		if got := joinFieldPath(tt.prefix, tt.rest); got != tt.want {
+			t.Errorf("joinFieldPath(%q, %q) = %q, want %q", tt.prefix, tt.rest, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package integration
+
+// Types bound to the native metadata registry. This file has its own
+// preamble because native/cgocopy_metadata.h and cgocopy_macros.h declare
+// different structs under the same names.
+
+/*
+#include "native/auto_structs.h"
+*/
+import "C"
+import (
+	"unsafe"
+
+	cgocopy "github.com/shaban/cgocopy/pkg/cgocopy"
+)
+
+// AutoVec matches the C AutoVec struct.
+type AutoVec struct {
+	X float64 `cgocopy:"x"`
+	Y float64 `cgocopy:"y"`
+}
+
+// Particle matches the C particle_t struct, named by its blank field tag.
+type Particle struct {
+	_        struct{}   `cgocopy:"particle_t"`
+	ID       int32      `cgocopy:"id"`
+	Label    string     `cgocopy:"label"`
+	Position AutoVec    `cgocopy:"position"`
+	Weights  [3]float32 `cgocopy:"weights"`
+}
+
+// Route matches the C route_t struct, an array of nested structs.
+type Route struct {
+	_     struct{}   `cgocopy:"route_t"`
+	ID    int32      `cgocopy:"id"`
+	Stops [2]AutoVec `cgocopy:"stops"`
+}
+
+func init() {
+	// Registers AutoVec as well, from the native registry
+	if err := cgocopy.PrecompileAuto[Particle](); err != nil {
+		panic(err)
+	}
+}
+
+func CreateParticle(id int32, label string, x, y float64) unsafe.Pointer {
+	cLabel := C.CString(label)
+	defer C.free(unsafe.Pointer(cLabel))
+	return unsafe.Pointer(C.create_particle(C.int(id), cLabel, C.double(x), C.double(y)))
+}
+
+func CreateRoute(id int32) unsafe.Pointer {
+	return unsafe.Pointer(C.create_route(C.int(id)))
+}
+
+func FreeParticle(ptr unsafe.Pointer) {
+	C.free_particle((*C.particle_t)(ptr))
+}
+
+// RunNativeRegistryStress registers, looks up and removes metadata from
+// threads C threads concurrently and returns the number of failures.
+func RunNativeRegistryStress(threads, count int) int {
+	return int(C.run_registry_stress(C.int(threads), C.int(count)))
+}
+
+// RegisterShadowAutoVec registers a second native AutoVec of size 1 that
+// shadows the original until unregistered.
+func RegisterShadowAutoVec() {
+	C.register_shadow_auto_vec()
+}
@@ -184,6 +184,33 @@ func FreeGameObject(ptr unsafe.Pointer) {
 	C.free_game_object((*C.GameObject)(ptr))
 }
 
+// GameObjectCMetadata returns the macro-generated C metadata of GameObject.
+func GameObjectCMetadata() cgocopy.CStructInfo {
+	return extractCMetadata(C.get_GameObject_metadata())
+}
+
+// Point3DCMetadata returns the macro-generated C metadata of Point3D.
+func Point3DCMetadata() cgocopy.CStructInfo {
+	return extractCMetadata(C.get_Point3D_metadata())
+}
+
+// TaggedCMetadata returns the macro-generated C metadata of Tagged.
+func TaggedCMetadata() cgocopy.CStructInfo {
+	return extractCMetadata(C.get_Tagged_metadata())
+}
+
+func CreateTagged(id int32, tag, code, label string) unsafe.Pointer {
+	cTag, cCode, cLabel := C.CString(tag), C.CString(code), C.CString(label)
+	defer C.free(unsafe.Pointer(cTag))
+	defer C.free(unsafe.Pointer(cCode))
+	defer C.free(unsafe.Pointer(cLabel))
+	return unsafe.Pointer(C.create_tagged(C.int(id), cTag, cCode, cLabel))
+}
+
+func FreeTagged(ptr unsafe.Pointer) {
+	C.free_tagged((*C.Tagged)(ptr))
+}
+
 func CreateAllTypes() unsafe.Pointer {
 	return unsafe.Pointer(C.create_all_types())
 }
@@ -1,6 +1,9 @@
 package integration
 
 import (
+	"errors"
+	"reflect"
+	"slices"
 	"testing"
 
 	cgocopy "github.com/shaban/cgocopy/pkg/cgocopy"
@@ -101,6 +104,61 @@ func TestIntegration_GameObject(t *testing.T) {
 	}
 }
 
+func TestIntegration_CopyToMapNested(t *testing.T) {
+	cObj := CreateGameObject("Player", 100.0, 200.0, 300.0, 10.0, 20.0, 30.0)
+	defer FreeGameObject(cObj)
+
+	info := GameObjectCMetadata()
+	if info.Fields[1].Type != "Point3D" {
+		t.Fatalf("position C type = %q, want %q", info.Fields[1].Type, "Point3D")
+	}
+
+	// No Go types are registered: Point3D is resolved by C type name
+	reg := cgocopy.NewRegistry()
+	reg.AddCMetadata(Point3DCMetadata())
+
+	fields, err := reg.CopyToMap(info, cObj)
+	if err != nil {
+		t.Fatalf("CopyToMap failed: %v", err)
+	}
+
+	if fields["name"] != "Player" {
+		t.Errorf("name = %v, want Player", fields["name"])
+	}
+	position, ok := fields["position"].(map[string]any)
+	if !ok {
+		t.Fatalf("position = %T, want map[string]any", fields["position"])
+	}
+	if position["x"] != 100.0 || position["y"] != 200.0 || position["z"] != 300.0 {
+		t.Errorf("position = %v, want x=100 y=200 z=300", position)
+	}
+	velocity, ok := fields["velocity"].(map[string]any)
+	if !ok || velocity["z"] != 30.0 {
+		t.Errorf("velocity = %v, want z=30", fields["velocity"])
+	}
+}
+
+func TestIntegration_CopyToMapCharArrays(t *testing.T) {
+	cTagged := CreateTagged(7, "abc", "code1234", "hello")
+	defer FreeTagged(cTagged)
+
+	info := TaggedCMetadata()
+	for _, f := range info.Fields[1:3] {
+		if f.Type != "char[]" || !f.IsArray || f.IsPointer || f.ArrayLen != int(f.Size) {
+			t.Errorf("C field %s = %+v, want a char[] array", f.Name, f)
+		}
+	}
+
+	fields, err := cgocopy.CopyToMap(info, cTagged)
+	if err != nil {
+		t.Fatalf("CopyToMap failed: %v", err)
+	}
+	// code fills all 8 bytes, with no NUL
+	if fields["tag"] != "abc" || fields["code"] != "code1234" || fields["label"] != "hello" {
+		t.Errorf("CopyToMap = %v, want tag=abc code=code1234 label=hello", fields)
+	}
+}
+
 // Test 5: All primitive types
 func TestIntegration_AllTypes(t *testing.T) {
 	cTypes := CreateAllTypes()
@@ -183,9 +241,8 @@ func TestIntegration_Validation(t *testing.T) {
 		t.Errorf("AllTypes validation failed: %v", err)
 	}
 
-	errors := cgocopy.ValidateAll()
-	if len(errors) > 0 {
-		t.Errorf("ValidateAll found errors: %v", errors)
+	if err := cgocopy.ValidateAll(); err != nil {
+		t.Errorf("ValidateAll found errors: %v", err)
 	}
 }
 
@@ -201,10 +258,10 @@ func TestIntegration_GetRegisteredTypes(t *testing.T) {
 	hasUser := false
 	hasGameObject := false
 	for _, typeName := range types {
-		if typeName == "User" {
+		if typeName == "integration.User" {
 			hasUser = true
 		}
-		if typeName == "GameObject" {
+		if typeName == "integration.GameObject" {
 			hasGameObject = true
 		}
 	}
@@ -216,3 +273,113 @@ func TestIntegration_GetRegisteredTypes(t *testing.T) {
 		t.Errorf("GameObject type not found in registered types: %v", types)
 	}
 }
+
+// Test: types bound through the native metadata registry
+func TestIntegration_PrecompileAuto(t *testing.T) {
+	cParticle := CreateParticle(9, "spark", 1.5, -2.5)
+	defer FreeParticle(cParticle)
+
+	particle, err := cgocopy.Copy[Particle](cParticle)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if particle.ID != 9 || particle.Label != "spark" {
+		t.Errorf("ID/Label mismatch: got %d/%q, want 9/%q", particle.ID, particle.Label, "spark")
+	}
+	if particle.Position != (AutoVec{X: 1.5, Y: -2.5}) {
+		t.Errorf("Position mismatch: got %+v, want {1.5 -2.5}", particle.Position)
+	}
+	if particle.Weights != [3]float32{0.5, 1, 1.5} {
+		t.Errorf("Weights mismatch: got %v, want [0.5 1 1.5]", particle.Weights)
+	}
+
+	if !cgocopy.IsRegistered[AutoVec]() {
+		t.Error("AutoVec not registered as a dependency of Particle")
+	}
+	if meta := cgocopy.GetMetadata[Particle](); meta.CTypeName != "particle_t" {
+		t.Errorf("CTypeName = %q, want particle_t", meta.CTypeName)
+	}
+}
+
+func TestIntegration_PrecompileAuto_StructArray(t *testing.T) {
+	cRoute := CreateRoute(3)
+	defer FreePointer(cRoute)
+
+	// A fresh registry: AutoVec must come from the "AutoVec[]" element type
+	reg := cgocopy.NewRegistry()
+	if err := reg.PrecompileAuto(reflect.TypeFor[Route]()); err != nil {
+		t.Fatalf("PrecompileAuto failed: %v", err)
+	}
+	if meta := reg.GetByCName("AutoVec"); meta == nil || meta.GoType != reflect.TypeFor[AutoVec]() {
+		t.Fatalf("GetByCName(AutoVec) = %v, want AutoVec registered", meta)
+	}
+
+	route, err := cgocopy.CopyWith[Route](reg, cRoute)
+	if err != nil {
+		t.Fatalf("CopyWith failed: %v", err)
+	}
+	want := [2]AutoVec{{X: 1, Y: 10}, {X: 2, Y: 20}}
+	if route.ID != 3 || route.Stops != want {
+		t.Errorf("Route = %+v, want ID 3 and stops %v", route, want)
+	}
+}
+
+func TestIntegration_PrecompileAuto_NotFound(t *testing.T) {
+	type Missing struct {
+		_ struct{} `cgocopy:"no_such_struct"`
+		A int32
+	}
+
+	if err := cgocopy.PrecompileAuto[Missing](); !errors.Is(err, cgocopy.ErrMetadataNotFound) {
+		t.Errorf("PrecompileAuto() error = %v, want ErrMetadataNotFound", err)
+	}
+}
+
+func TestIntegration_NativeRegistryConcurrent(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			if _, ok := cgocopy.LookupNativeCMetadata("particle_t"); !ok {
+				t.Error("particle_t missing during concurrent registration")
+				return
+			}
+			cgocopy.NativeCTypeNames()
+		}
+	}()
+
+	if failures := RunNativeRegistryStress(8, 500); failures != 0 {
+		t.Errorf("native registry stress: %d failed operations", failures)
+	}
+	<-done
+}
+
+func TestIntegration_NativeRegistryEnumerateAndUnregister(t *testing.T) {
+	names := cgocopy.NativeCTypeNames()
+	if !slices.Contains(names, "particle_t") || !slices.Contains(names, "AutoVec") {
+		t.Fatalf("NativeCTypeNames() = %v, want particle_t and AutoVec", names)
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("NativeCTypeNames() = %v, want sorted", names)
+	}
+
+	RegisterShadowAutoVec()
+	if info, _ := cgocopy.LookupNativeCMetadata("AutoVec"); info.Size != 1 {
+		t.Errorf("AutoVec size = %d, want the shadowing registration", info.Size)
+	}
+	if got := cgocopy.NativeCTypeNames(); len(got) != len(names) {
+		t.Errorf("NativeCTypeNames() = %v, want shadowed names listed once", got)
+	}
+
+	// Removing the shadow reveals the original registration
+	if !cgocopy.UnregisterNativeCMetadata("AutoVec") {
+		t.Fatal("UnregisterNativeCMetadata(AutoVec) = false")
+	}
+	if info, ok := cgocopy.LookupNativeCMetadata("AutoVec"); !ok || info.Size != 16 {
+		t.Errorf("AutoVec after unregister = %+v, %v, want the original", info, ok)
+	}
+	if cgocopy.UnregisterNativeCMetadata("no_such_struct") {
+		t.Error("UnregisterNativeCMetadata(no_such_struct) = true")
+	}
+}
@@ -0,0 +1,142 @@
+#ifndef INTEGRATION_AUTO_STRUCTS_H
+#define INTEGRATION_AUTO_STRUCTS_H
+
+// Structs registered with the constructor-based native metadata registry
+// (native/cgocopy_metadata.h) and bound with PrecompileAuto.
+
+#include <stdlib.h>
+#include <string.h>
+#include "../../../../native/cgocopy_metadata.h"
+
+typedef struct {
+    double x;
+    double y;
+} AutoVec;
+
+typedef struct {
+    int id;
+    char* label;
+    AutoVec position;
+    float weights[3];
+} particle_t;
+
+CGOCOPY_STRUCT_BEGIN(AutoVec)
+    CGOCOPY_FIELD_PRIMITIVE(AutoVec, x, double),
+    CGOCOPY_FIELD_PRIMITIVE(AutoVec, y, double)
+CGOCOPY_STRUCT_END(AutoVec)
+
+CGOCOPY_STRUCT_BEGIN(particle_t)
+    CGOCOPY_FIELD_PRIMITIVE(particle_t, id, int),
+    CGOCOPY_FIELD_STRING(particle_t, label),
+    CGOCOPY_FIELD_STRUCT(particle_t, position, AutoVec),
+    CGOCOPY_FIELD_ARRAY(particle_t, weights, float, 3)
+CGOCOPY_STRUCT_END(particle_t)
+
+typedef struct {
+    int id;
+    AutoVec stops[2];
+} route_t;
+
+CGOCOPY_STRUCT_BEGIN(route_t)
+    CGOCOPY_FIELD_PRIMITIVE(route_t, id, int),
+    CGOCOPY_FIELD_ARRAY_STRUCT(route_t, stops, AutoVec, 2)
+CGOCOPY_STRUCT_END(route_t)
+
+static route_t* create_route(int id) {
+    route_t* r = (route_t*)malloc(sizeof(route_t));
+    r->id = id;
+    for (int i = 0; i < 2; i++) {
+        r->stops[i].x = i + 1;
+        r->stops[i].y = (i + 1) * 10;
+    }
+    return r;
+}
+
+static particle_t* create_particle(int id, const char* label, double x, double y) {
+    particle_t* p = (particle_t*)malloc(sizeof(particle_t));
+    p->id = id;
+    p->label = strdup(label);
+    p->position.x = x;
+    p->position.y = y;
+    for (int i = 0; i < 3; i++) {
+        p->weights[i] = (float)(i + 1) / 2;
+    }
+    return p;
+}
+
+static void free_particle(particle_t* p) {
+    if (p) {
+        free(p->label);
+        free(p);
+    }
+}
+
+// Registers, looks up and removes heap-allocated metadata from several
+// threads at once. Returns the number of failed operations.
+#include <pthread.h>
+#include <stdio.h>
+
+typedef struct {
+    int thread;
+    int count;
+    int failures;
+} registry_stress_arg;
+
+static void* registry_stress_worker(void* raw) {
+    registry_stress_arg* arg = (registry_stress_arg*)raw;
+    cgocopy_struct_registry_node* nodes = calloc(arg->count, sizeof(*nodes));
+    cgocopy_struct_info* infos = calloc(arg->count, sizeof(*infos));
+    char (*names)[32] = calloc(arg->count, sizeof(*names));
+
+    for (int i = 0; i < arg->count; i++) {
+        snprintf(names[i], sizeof(names[i]), "stress_%d_%d", arg->thread, i);
+        infos[i].name = names[i];
+        nodes[i].info = &infos[i];
+        if (!cgocopy_registry_add(&nodes[i]) || cgocopy_lookup_struct_info(names[i]) != &infos[i]) {
+            arg->failures++;
+        }
+        cgocopy_struct_info* copy = cgocopy_copy_struct_info(names[i]);
+        if (copy == NULL || copy == &infos[i] || strcmp(copy->name, names[i]) != 0) {
+            arg->failures++;
+        }
+        free(copy);
+    }
+    for (int i = 0; i < arg->count; i++) {
+        if (!cgocopy_registry_remove(&nodes[i]) || cgocopy_lookup_struct_info(names[i]) != NULL) {
+            arg->failures++;
+        }
+    }
+
+    free(nodes);
+    free(infos);
+    free(names);
+    return NULL;
+}
+
+static int run_registry_stress(int threads, int count) {
+    pthread_t ids[64];
+    registry_stress_arg args[64];
+    if (threads > 64) {
+        threads = 64;
+    }
+    for (int t = 0; t < threads; t++) {
+        args[t] = (registry_stress_arg){.thread = t, .count = count};
+        pthread_create(&ids[t], NULL, registry_stress_worker, &args[t]);
+    }
+    int failures = 0;
+    for (int t = 0; t < threads; t++) {
+        pthread_join(ids[t], NULL);
+        failures += args[t].failures;
+    }
+    return failures;
+}
+
+// Registers a second AutoVec that shadows the constructor-registered one.
+static cgocopy_struct_info shadow_auto_vec_info = {.name = "AutoVec", .size = 1};
+static cgocopy_struct_registry_node shadow_auto_vec_node = {.info = &shadow_auto_vec_info};
+
+static void register_shadow_auto_vec(void) {
+    cgocopy_registry_add(&shadow_auto_vec_node);
+}
+
+#endif // INTEGRATION_AUTO_STRUCTS_H
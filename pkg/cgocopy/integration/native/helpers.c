@@ -85,3 +85,19 @@ AllTypes* create_all_types(void) {
     t->flag = 1;
     return t;
 }
+
+Tagged* create_tagged(int id, const char* tag, const char* code, const char* label) {
+    Tagged* t = (Tagged*)calloc(1, sizeof(Tagged));
+    t->id = id;
+    strncpy(t->tag, tag, sizeof(t->tag));
+    strncpy(t->code, code, sizeof(t->code));
+    t->label = strdup(label);
+    return t;
+}
+
+void free_tagged(Tagged* t) {
+    if (t) {
+        free(t->label);
+        free(t);
+    }
+}
@@ -20,5 +20,7 @@ const cgocopy_struct_info* get_GameObject_metadata(void);
 
 const cgocopy_struct_info* get_AllTypes_metadata(void);
 
+const cgocopy_struct_info* get_Tagged_metadata(void);
+
 
 #endif // METADATA_API_H
@@ -53,6 +53,14 @@ typedef struct {
     _Bool    flag;
 } AllTypes;
 
+// Test struct 6: Inline char arrays, one as large as a char*
+typedef struct {
+    int id;
+    char tag[4];
+    char code[8];
+    char* label;
+} Tagged;
+
 // Constructor/destructor functions
 SimplePerson* create_simple_person(int id, double score, _Bool active);
 User* create_user(int id, const char* username, const char* email);
@@ -62,5 +70,7 @@ void free_student(Student* s);
 GameObject* create_game_object(const char* name, double px, double py, double pz, double vx, double vy, double vz);
 void free_game_object(GameObject* obj);
 AllTypes* create_all_types(void);
+Tagged* create_tagged(int id, const char* tag, const char* code, const char* label);
+void free_tagged(Tagged* t);
 
 #endif // INTEGRATION_STRUCTS_H
@@ -58,8 +58,8 @@ const cgocopy_struct_info* get_Point3D_metadata(void) {
 // Metadata for GameObject
 CGOCOPY_STRUCT(GameObject,
     CGOCOPY_FIELD(GameObject, name),
-    CGOCOPY_FIELD(GameObject, position),
-    CGOCOPY_FIELD(GameObject, velocity)
+    CGOCOPY_STRUCT_FIELD(GameObject, position, Point3D),
+    CGOCOPY_STRUCT_FIELD(GameObject, velocity, Point3D)
 )
 
 const cgocopy_struct_info* get_GameObject_metadata(void) {
@@ -86,3 +86,16 @@ const cgocopy_struct_info* get_AllTypes_metadata(void) {
     return &cgocopy_metadata_AllTypes;
 }
 
+
+// Metadata for Tagged
+CGOCOPY_STRUCT(Tagged,
+    CGOCOPY_FIELD(Tagged, id),
+    CGOCOPY_FIELD(Tagged, tag),
+    CGOCOPY_FIELD(Tagged, code),
+    CGOCOPY_FIELD(Tagged, label)
+)
+
+const cgocopy_struct_info* get_Tagged_metadata(void) {
+    return &cgocopy_metadata_Tagged;
+}
+
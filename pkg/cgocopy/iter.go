@@ -0,0 +1,142 @@
+package cgocopy2
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"reflect"
+	"unsafe"
+)
+
+// Each returns an iterator that decodes n contiguous C structs starting at
+// cArray one element at a time, yielding (index, value) pairs. Elements are
+// laid out with a stride of the registered C struct size.
+//
+// Iteration stops at the first decoding error; the returned error function
+// reports it (as an *ElementError) once the loop has finished.
+//
+// Example:
+//
+//	users, errf := cgocopy2.Each[User](unsafe.Pointer(cUsers), count)
+//	for i, user := range users {
+//	    fmt.Fprintf(w, "%d: %s\n", i, user.Name)
+//	}
+//	if err := errf(); err != nil {
+//	    return err
+//	}
+func Each[T any](cArray unsafe.Pointer, n int) (iter.Seq2[int, T], func() error) {
+	return EachWith[T](globalRegistry, cArray, n)
+}
+
+// EachWith is like Each but resolves T, and every nested type, in reg.
+func EachWith[T any](reg *Registry, cArray unsafe.Pointer, n int) (iter.Seq2[int, T], func() error) {
+	it := &arrayIter[T]{registry: reg, cArray: cArray, n: n}
+	seq := func(yield func(int, T) bool) {
+		var value T
+		it.run(&value, func(i int) bool {
+			return yield(i, value)
+		})
+	}
+	return seq, it.Err
+}
+
+// EachReuse is like Each but decodes every element into a single destination
+// value and yields a pointer to it, avoiding a copy per element. The pointed-to
+// value is overwritten by the next iteration and must not be retained.
+func EachReuse[T any](cArray unsafe.Pointer, n int) (iter.Seq2[int, *T], func() error) {
+	return EachReuseWith[T](globalRegistry, cArray, n)
+}
+
+// EachReuseWith is like EachReuse but resolves T, and every nested type, in
+// reg.
+func EachReuseWith[T any](reg *Registry, cArray unsafe.Pointer, n int) (iter.Seq2[int, *T], func() error) {
+	it := &arrayIter[T]{registry: reg, cArray: cArray, n: n}
+	seq := func(yield func(int, *T) bool) {
+		var value T
+		it.run(&value, func(i int) bool {
+			return yield(i, &value)
+		})
+	}
+	return seq, it.Err
+}
+
+// EachBuffer is like Each for a C buffer of size bytes holding packed structs.
+// size must be a multiple of the registered C struct size.
+func EachBuffer[T any](buf unsafe.Pointer, size uintptr) (iter.Seq2[int, T], func() error) {
+	return EachBufferWith[T](globalRegistry, buf, size)
+}
+
+// EachBufferWith is like EachBuffer but resolves T, and every nested type,
+// in reg.
+func EachBufferWith[T any](reg *Registry, buf unsafe.Pointer, size uintptr) (iter.Seq2[int, T], func() error) {
+	var zero T
+	goType := reflect.TypeOf(zero)
+
+	metadata := reg.Get(goType)
+	if metadata == nil || metadata.Size == 0 {
+		err := newCopyError(goType, "", "type not registered", ErrNotRegistered)
+		return func(func(int, T) bool) {}, func() error { return err }
+	}
+	if size%metadata.Size != 0 {
+		err := newCopyError(goType, "",
+			fmt.Sprintf("buffer size %d is not a multiple of struct size %d", size, metadata.Size),
+			ErrFieldMismatch)
+		return func(func(int, T) bool) {}, func() error { return err }
+	}
+
+	return EachWith[T](reg, buf, int(size/metadata.Size))
+}
+
+// arrayIter holds the state shared by an iterator and its error accessor.
+type arrayIter[T any] struct {
+	registry *Registry
+	cArray   unsafe.Pointer
+	n        int
+	err      error
+}
+
+// Err returns the error that stopped the most recent iteration, if any.
+func (it *arrayIter[T]) Err() error {
+	return it.err
+}
+
+// run decodes each element into *dst and calls yield with its index,
+// stopping when yield returns false or an element fails.
+func (it *arrayIter[T]) run(dst *T, yield func(int) bool) {
+	it.err = nil
+	if it.n <= 0 {
+		return
+	}
+
+	goType := reflect.TypeOf(*dst)
+	if goType.Kind() != reflect.Struct {
+		it.err = newCopyError(goType, "", "only struct types can be iterated", ErrInvalidType)
+		return
+	}
+	if it.cArray == nil {
+		it.err = ErrNilPointer
+		return
+	}
+
+	metadata := it.registry.Get(goType)
+	if metadata == nil {
+		it.err = newCopyError(goType, "", "type not registered", ErrNotRegistered)
+		return
+	}
+
+	region := traceRegion(context.Background(), "Each", metadata.TypeName)
+	defer endTraceRegion(region)
+
+	value := reflect.ValueOf(dst).Elem()
+	for i := 0; i < it.n; i++ {
+		value.SetZero()
+		cPtr := unsafe.Pointer(uintptr(it.cArray) + uintptr(i)*metadata.Size)
+		if err := copyTopLevel(it.registry, value, cPtr, metadata, Limits{}); err != nil {
+			it.err = &ElementError{Index: i, Cause: err}
+			return
+		}
+		if !yield(i) {
+			return
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package cgocopy2
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+func TestEach(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[BulkRecord](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	cArray := []BulkRecord{{ID: 1, Score: 1.5}, {ID: 2, Score: 2.5}, {ID: 3, Score: 3.5}}
+
+	seq, errf := Each[BulkRecord](unsafe.Pointer(&cArray[0]), len(cArray))
+	var got []BulkRecord
+	for i, record := range seq {
+		if i != len(got) {
+			t.Errorf("index = %d, want %d", i, len(got))
+		}
+		got = append(got, record)
+	}
+	if err := errf(); err != nil {
+		t.Fatalf("Each() error = %v", err)
+	}
+
+	if len(got) != len(cArray) {
+		t.Fatalf("got %d records, want %d", len(got), len(cArray))
+	}
+	for i := range got {
+		if got[i] != cArray[i] {
+			t.Errorf("record %d = %+v, want %+v", i, got[i], cArray[i])
+		}
+	}
+}
+
+func TestEach_EarlyBreak(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[BulkRecord](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	cArray := make([]BulkRecord, 10)
+	seq, errf := Each[BulkRecord](unsafe.Pointer(&cArray[0]), len(cArray))
+
+	count := 0
+	for range seq {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	if count != 3 || errf() != nil {
+		t.Errorf("count = %d, err = %v, want 3 and nil", count, errf())
+	}
+}
+
+func TestEachReuse(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[BulkRecord](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	cArray := []BulkRecord{{ID: 10}, {ID: 20}}
+	seq, errf := EachReuse[BulkRecord](unsafe.Pointer(&cArray[0]), len(cArray))
+
+	var first *BulkRecord
+	var ids []int32
+	for _, record := range seq {
+		if first == nil {
+			first = record
+		} else if record != first {
+			t.Error("EachReuse() yielded a different destination pointer")
+		}
+		ids = append(ids, record.ID)
+	}
+	if err := errf(); err != nil {
+		t.Fatalf("EachReuse() error = %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 10 || ids[1] != 20 {
+		t.Errorf("ids = %v, want [10 20]", ids)
+	}
+}
+
+func TestEach_ElementError(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[LimitedRecord](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+	if err := SetTypeLimits[LimitedRecord](Limits{MaxElements: 1}); err != nil {
+		t.Fatalf("SetTypeLimits() error = %v", err)
+	}
+
+	cArray := make([]LimitedRecord, 4)
+	cArray[2].Items = []int32{1, 2}
+
+	seq, errf := Each[LimitedRecord](unsafe.Pointer(&cArray[0]), len(cArray))
+	count := 0
+	for range seq {
+		count++
+	}
+
+	if count != 2 {
+		t.Errorf("yielded %d records before the error, want 2", count)
+	}
+
+	var elemErr *ElementError
+	if err := errf(); !errors.As(err, &elemErr) || elemErr.Index != 2 {
+		t.Errorf("Each() error = %v, want ElementError at index 2", err)
+	}
+}
+
+func TestEach_NotRegistered(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	cArray := make([]BulkRecord, 2)
+	seq, errf := Each[BulkRecord](unsafe.Pointer(&cArray[0]), len(cArray))
+	for range seq {
+		t.Fatal("Each() yielded a value for an unregistered type")
+	}
+	if !errors.Is(errf(), ErrNotRegistered) {
+		t.Errorf("Each() error = %v, want ErrNotRegistered", errf())
+	}
+}
+
+func TestEachBuffer(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[BulkRecord](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	cArray := []BulkRecord{{ID: 1}, {ID: 2}, {ID: 3}}
+	size := unsafe.Sizeof(cArray[0]) * uintptr(len(cArray))
+
+	seq, errf := EachBuffer[BulkRecord](unsafe.Pointer(&cArray[0]), size)
+	count := 0
+	for range seq {
+		count++
+	}
+	if count != 3 || errf() != nil {
+		t.Errorf("count = %d, err = %v, want 3 and nil", count, errf())
+	}
+
+	_, errf = EachBuffer[BulkRecord](unsafe.Pointer(&cArray[0]), size-1)
+	if !errors.Is(errf(), ErrFieldMismatch) {
+		t.Errorf("EachBuffer() error = %v, want ErrFieldMismatch for ragged buffer", errf())
+	}
+}
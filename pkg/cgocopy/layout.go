@@ -0,0 +1,362 @@
+package cgocopy2
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// StructLayout compares the Go and C layouts of a registered type, as used
+// by Copy. It is produced by LayoutReport.
+type StructLayout struct {
+	// TypeName is the package-qualified Go type name.
+	TypeName string
+
+	// CTypeName is the C struct name.
+	CTypeName string
+
+	// HasCMetadata is false for types registered with Precompile, whose C
+	// layout is assumed to match the Go layout.
+	HasCMetadata bool
+
+	// GoSize and CSize are the struct sizes in bytes.
+	GoSize uintptr
+	CSize  uintptr
+
+	// Fields lists the copied fields in Go declaration order.
+	Fields []FieldLayout
+
+	// GoTrailingPadding and CTrailingPadding are the bytes after the last
+	// field of each struct.
+	GoTrailingPadding uintptr
+	CTrailingPadding  uintptr
+
+	// UnmappedCFields lists the C fields no Go field reads, in C order.
+	UnmappedCFields []CFieldInfo
+}
+
+// FieldLayout describes where one Go field and the C field it is copied
+// from live in memory.
+type FieldLayout struct {
+	// Name is the Go field name and CName the C field name.
+	Name  string
+	CName string
+
+	// GoType is the Go field type and CType the C type name ("" without C
+	// metadata).
+	GoType string
+	CType  string
+
+	// GoOffset and GoSize locate the field in the Go struct.
+	GoOffset uintptr
+	GoSize   uintptr
+
+	// COffset and CSize locate the field in the C struct.
+	COffset uintptr
+	CSize   uintptr
+
+	// GoPadding and CPadding are the gaps before the field, after the end of
+	// the preceding field in memory.
+	GoPadding uintptr
+	CPadding  uintptr
+
+	// Conversion describes how Copy turns the C value into the Go value
+	// ("" for a direct copy of a primitive of the same type).
+	Conversion string
+}
+
+// LayoutReport returns the Go/C layout comparison of T in the global
+// registry. See Registry.LayoutReport.
+//
+// Example:
+//
+//	report, err := cgocopy2.LayoutReport[User]()
+//	if err != nil {
+//	    return err
+//	}
+//	fmt.Print(report.Table())
+func LayoutReport[T any]() (*StructLayout, error) {
+	return globalRegistry.LayoutReport(reflect.TypeFor[T]())
+}
+
+// LayoutReports returns the layout comparisons of every type in the global
+// registry. See Registry.LayoutReports.
+func LayoutReports() []*StructLayout {
+	return globalRegistry.LayoutReports()
+}
+
+// LayoutReport compares the Go layout of goType with the C layout it was
+// registered with in r: offsets, sizes and padding on both sides, the C
+// fields no Go field reads and the conversion applied to each field.
+func (r *Registry) LayoutReport(goType reflect.Type) (*StructLayout, error) {
+	if goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+
+	metadata := r.Get(goType)
+	if metadata == nil {
+		return nil, newValidationError(qualifiedTypeName(goType), "", goType, "",
+			fmt.Sprintf("type not registered - call Precompile[%s]() first", goType.Name()))
+	}
+	return newStructLayout(metadata), nil
+}
+
+// LayoutReports returns the layout comparisons of every type registered in
+// r, sorted by type name.
+func (r *Registry) LayoutReports() []*StructLayout {
+	all := r.all()
+	layouts := make([]*StructLayout, 0, len(all))
+	for _, metadata := range all {
+		layouts = append(layouts, newStructLayout(metadata))
+	}
+	sort.Slice(layouts, func(i, j int) bool {
+		return layouts[i].TypeName < layouts[j].TypeName
+	})
+	return layouts
+}
+
+// newStructLayout builds the layout comparison of a registered type.
+func newStructLayout(metadata *StructMetadata) *StructLayout {
+	layout := &StructLayout{
+		TypeName:     metadata.TypeName,
+		CTypeName:    metadata.CTypeName,
+		HasCMetadata: metadata.CInfo != nil,
+		GoSize:       metadata.GoType.Size(),
+		CSize:        metadata.Size,
+	}
+
+	goRanges := goFieldRanges(metadata.GoType, 0)
+	var cRanges []byteRange
+	if metadata.CInfo != nil {
+		for _, cField := range metadata.CInfo.Fields {
+			cRanges = append(cRanges, byteRange{cField.Offset, cField.Offset + cField.Size})
+		}
+	}
+
+	// Offsets of the C fields some Go field reads
+	read := make(map[uintptr]bool)
+
+	for i := range metadata.Fields {
+		field := &metadata.Fields[i]
+		if field.Skip {
+			continue
+		}
+
+		goOffset := goFieldOffset(metadata.GoType, field)
+		if metadata.CInfo == nil {
+			cRanges = append(cRanges, byteRange{field.Offset, field.Offset + field.Size})
+		}
+
+		layout.Fields = append(layout.Fields, FieldLayout{
+			Name:       field.Name,
+			CName:      field.CName,
+			GoType:     field.ReflectType.String(),
+			CType:      field.CType,
+			GoOffset:   goOffset,
+			GoSize:     field.ReflectType.Size(),
+			COffset:    field.Offset,
+			CSize:      field.Size,
+			Conversion: describeConversion(field),
+		})
+
+		read[field.Offset] = true
+		if field.Map != nil {
+			read[field.Map.LenOffset] = true
+			if field.Map.Paired {
+				read[field.Map.ValuesOffset] = true
+			}
+		}
+	}
+
+	for i := range layout.Fields {
+		field := &layout.Fields[i]
+		field.GoPadding = field.GoOffset - endBefore(goRanges, field.GoOffset)
+		field.CPadding = field.COffset - endBefore(cRanges, field.COffset)
+	}
+	layout.GoTrailingPadding = trailingPadding(goRanges, layout.GoSize)
+	layout.CTrailingPadding = trailingPadding(cRanges, layout.CSize)
+
+	if metadata.CInfo != nil {
+		for _, cField := range metadata.CInfo.Fields {
+			if !read[cField.Offset] {
+				layout.UnmappedCFields = append(layout.UnmappedCFields, cField)
+			}
+		}
+	}
+
+	return layout
+}
+
+// byteRange is the half-open byte range [start, end) of a field.
+type byteRange struct {
+	start, end uintptr
+}
+
+// goFieldRanges returns the byte ranges of every field of a Go struct,
+// descending into the embedded and flattened structs that collectFields
+// flattens (see shouldFlatten) so that padding inside them is seen.
+func goFieldRanges(goType reflect.Type, base uintptr) []byteRange {
+	var ranges []byteRange
+	for i := 0; i < goType.NumField(); i++ {
+		field := goType.Field(i)
+		offset := base + field.Offset
+		tag := field.Tag.Get("cgocopy")
+		cName, _ := parseTag(tag)
+		if shouldFlatten(field, cName, parseTagOptions(tag)) {
+			ranges = append(ranges, goFieldRanges(field.Type, offset)...)
+			continue
+		}
+		ranges = append(ranges, byteRange{offset, offset + field.Type.Size()})
+	}
+	return ranges
+}
+
+// goFieldOffset returns the offset of a field in the top-level Go struct,
+// following IndexPath for flattened fields.
+func goFieldOffset(goType reflect.Type, field *FieldInfo) uintptr {
+	path := field.IndexPath
+	if path == nil {
+		path = []int{field.Index}
+	}
+
+	var offset uintptr
+	t := goType
+	for _, index := range path {
+		sf := t.Field(index)
+		offset += sf.Offset
+		t = sf.Type
+	}
+	return offset
+}
+
+// endBefore returns the end of the last field that ends at or before offset
+// (0 if none), i.e. where the gap before a field at offset starts.
+func endBefore(ranges []byteRange, offset uintptr) uintptr {
+	var end uintptr
+	for _, r := range ranges {
+		if r.end <= offset && r.end > end {
+			end = r.end
+		}
+	}
+	return end
+}
+
+// trailingPadding returns the bytes between the end of the last field and
+// size.
+func trailingPadding(ranges []byteRange, size uintptr) uintptr {
+	var end uintptr
+	for _, r := range ranges {
+		end = max(end, r.end)
+	}
+	if end >= size {
+		return 0
+	}
+	return size - end
+}
+
+// describeConversion describes how Copy converts the C value of a field.
+func describeConversion(field *FieldInfo) string {
+	var parts []string
+	switch field.Type {
+	case FieldTypePrimitive:
+		goKind := field.ReflectType.Kind().String()
+		if field.CType != "" && field.CType != goKind {
+			parts = append(parts, field.CType+" → "+goKind)
+		}
+		if field.CType != "" && field.Size != field.ReflectType.Size() {
+			parts = append(parts, "size mismatch")
+		}
+	case FieldTypeString:
+		parts = append(parts, "char* → "+field.ReflectType.String())
+	case FieldTypeStruct:
+		parts = append(parts, "nested struct")
+	case FieldTypeArray:
+		parts = append(parts, fmt.Sprintf("array of %d", field.ArrayLen))
+	case FieldTypeSlice:
+		parts = append(parts, "C array → slice")
+	case FieldTypePointer:
+		parts = append(parts, "pointer → new "+field.ElemType.String())
+	case FieldTypeOpaque:
+		parts = append(parts, "raw address")
+	case FieldTypeMap:
+		if field.Map != nil && field.Map.Paired {
+			parts = append(parts, "key/value arrays → map")
+		} else {
+			parts = append(parts, "pair array → map")
+		}
+	case FieldTypeLazy:
+		parts = append(parts, "deferred pointer")
+	}
+
+	if field.Required {
+		parts = append(parts, "required")
+	}
+	if field.HasDefault {
+		parts = append(parts, "default="+field.Default)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Table formats the layout as a human-readable table.
+func (l *StructLayout) Table() string {
+	var sb strings.Builder
+	_ = WriteLayoutTable(&sb, l)
+	return sb.String()
+}
+
+// WriteLayoutTable writes layouts to w as human-readable tables, one per
+// type, separated by blank lines.
+func WriteLayoutTable(w io.Writer, layouts ...*StructLayout) error {
+	for i, l := range layouts {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if err := l.writeTable(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *StructLayout) writeTable(w io.Writer) error {
+	var buf bytes.Buffer
+
+	cSource := "C metadata"
+	if !l.HasCMetadata {
+		cSource = "no C metadata, Go layout assumed"
+	}
+	fmt.Fprintf(&buf, "%s → C %s (Go %d bytes, C %d bytes, %s)\n", l.TypeName, l.CTypeName, l.GoSize, l.CSize, cSource)
+
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tC FIELD\tGO TYPE\tC TYPE\tGO OFF\tGO SIZE\tGO PAD\tC OFF\tC SIZE\tC PAD\tCONVERSION")
+	for _, f := range l.Fields {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			f.Name, f.CName, f.GoType, orDash(f.CType),
+			f.GoOffset, f.GoSize, f.GoPadding, f.COffset, f.CSize, f.CPadding, orDash(f.Conversion))
+	}
+	tw.Flush()
+
+	if l.GoTrailingPadding > 0 || l.CTrailingPadding > 0 {
+		fmt.Fprintf(&buf, "trailing padding: Go %d, C %d\n", l.GoTrailingPadding, l.CTrailingPadding)
+	}
+	for _, cField := range l.UnmappedCFields {
+		fmt.Fprintf(&buf, "unmapped C field: %s (%s, offset %d, size %d)\n", cField.Name, orDash(cField.Type), cField.Offset, cField.Size)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// orDash returns s, or "-" for an empty table cell.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,201 @@
+package cgocopy2
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type LayoutDevice struct {
+	ID    uint8
+	Count int64
+	Name  string `cgocopy:"name,required"`
+}
+
+// layoutDeviceInfo describes a packed C struct with a reserved field that
+// no Go field reads.
+var layoutDeviceInfo = CStructInfo{
+	Name: "layout_device",
+	Size: 24,
+	Fields: []CFieldInfo{
+		{Name: "ID", Type: "uint8", Offset: 0, Size: 1},
+		{Name: "Count", Type: "int32", Offset: 4, Size: 4},
+		{Name: "reserved", Type: "uint32", Offset: 8, Size: 4},
+		{Name: "name", Type: "string", Offset: 16, Size: 8, IsPointer: true},
+	},
+}
+
+func TestLayoutReport(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := PrecompileWithC[LayoutDevice](layoutDeviceInfo); err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+
+	report, err := LayoutReport[LayoutDevice]()
+	if err != nil {
+		t.Fatalf("LayoutReport() error = %v", err)
+	}
+
+	if !report.HasCMetadata || report.CTypeName != "layout_device" || report.GoSize != 32 || report.CSize != 24 {
+		t.Errorf("report header = %+v", report)
+	}
+
+	want := []FieldLayout{
+		{Name: "ID", CName: "ID", GoType: "uint8", CType: "uint8",
+			GoOffset: 0, GoSize: 1, COffset: 0, CSize: 1},
+		{Name: "Count", CName: "Count", GoType: "int64", CType: "int32",
+			GoOffset: 8, GoSize: 8, GoPadding: 7, COffset: 4, CSize: 4, CPadding: 3,
+			Conversion: "int32 → int64, size mismatch"},
+		{Name: "Name", CName: "name", GoType: "string", CType: "string",
+			GoOffset: 16, GoSize: 16, COffset: 16, CSize: 8, CPadding: 4,
+			Conversion: "char* → string, required"},
+	}
+	if !reflect.DeepEqual(report.Fields, want) {
+		t.Errorf("Fields =\n%+v\nwant\n%+v", report.Fields, want)
+	}
+
+	if len(report.UnmappedCFields) != 1 || report.UnmappedCFields[0].Name != "reserved" {
+		t.Errorf("UnmappedCFields = %+v, want [reserved]", report.UnmappedCFields)
+	}
+
+	table := report.Table()
+	for _, want := range []string{
+		"layout_device (Go 32 bytes, C 24 bytes, C metadata)",
+		"FIELD", "C PAD", "int32 → int64",
+		"unmapped C field: reserved (uint32, offset 8, size 4)",
+	} {
+		if !strings.Contains(table, want) {
+			t.Errorf("Table() missing %q:\n%s", want, table)
+		}
+	}
+}
+
+func TestLayoutReport_GoLayout(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Padded struct {
+		A int8
+		B int32
+		C int8
+	}
+	if err := Precompile[Padded](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	report, err := LayoutReport[Padded]()
+	if err != nil {
+		t.Fatalf("LayoutReport() error = %v", err)
+	}
+	if report.HasCMetadata {
+		t.Error("HasCMetadata = true for a Precompile'd type")
+	}
+	if got := report.Fields[1]; got.GoPadding != 3 || got.CPadding != 3 {
+		t.Errorf("B padding = Go %d, C %d, want 3, 3", got.GoPadding, got.CPadding)
+	}
+	if report.GoTrailingPadding != 3 || report.CTrailingPadding != 3 {
+		t.Errorf("trailing padding = Go %d, C %d, want 3, 3", report.GoTrailingPadding, report.CTrailingPadding)
+	}
+	if !strings.Contains(report.Table(), "no C metadata") {
+		t.Errorf("Table() does not say the C layout is assumed:\n%s", report.Table())
+	}
+}
+
+func TestLayoutReport_EmbeddedOffsets(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Header struct {
+		Kind uint16
+		Len  uint32
+	}
+	type Packet struct {
+		Header
+		Payload int64
+	}
+	if err := Precompile[Packet](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	report, err := LayoutReport[Packet]()
+	if err != nil {
+		t.Fatalf("LayoutReport() error = %v", err)
+	}
+	if got := report.Fields[1]; got.Name != "Len" || got.GoOffset != 4 || got.GoPadding != 2 {
+		t.Errorf("Len layout = %+v, want offset 4 after 2 bytes of padding", got)
+	}
+}
+
+func TestLayoutReport_NamedEmbeddedStruct(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	// A C-named embedded struct is a nested field, not flattened, so its
+	// own trailing padding is not padding before Tail
+	type Header struct {
+		Len  uint32
+		Kind uint16
+	}
+	type Packet struct {
+		Header `cgocopy:"hdr"`
+		Tail   uint16
+	}
+	if err := Precompile[Header](); err != nil {
+		t.Fatalf("Precompile[Header]() error = %v", err)
+	}
+	if err := Precompile[Packet](); err != nil {
+		t.Fatalf("Precompile[Packet]() error = %v", err)
+	}
+
+	report, err := LayoutReport[Packet]()
+	if err != nil {
+		t.Fatalf("LayoutReport() error = %v", err)
+	}
+	if len(report.Fields) != 2 || report.Fields[0].CName != "hdr" {
+		t.Fatalf("Fields = %+v, want hdr and Tail", report.Fields)
+	}
+	if got := report.Fields[1]; got.GoOffset != 8 || got.GoPadding != 0 {
+		t.Errorf("Tail layout = %+v, want offset 8 with no padding", got)
+	}
+}
+
+func TestLayoutReports(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if _, err := LayoutReport[LayoutDevice](); err == nil {
+		t.Error("LayoutReport() of an unregistered type error = nil")
+	}
+
+	if err := PrecompileWithC[LayoutDevice](layoutDeviceInfo); err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+	if err := Precompile[SimpleStruct](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	reports := LayoutReports()
+	if len(reports) != 2 || reports[0].TypeName > reports[1].TypeName {
+		t.Fatalf("LayoutReports() = %d reports, want 2 sorted by name", len(reports))
+	}
+
+	var sb strings.Builder
+	if err := WriteLayoutTable(&sb, reports...); err != nil {
+		t.Fatalf("WriteLayoutTable() error = %v", err)
+	}
+	if !strings.Contains(sb.String(), reports[0].TypeName) || !strings.Contains(sb.String(), reports[1].TypeName) {
+		t.Errorf("WriteLayoutTable() missing a type:\n%s", sb.String())
+	}
+
+	// Scoped registries report their own types only
+	if got := NewRegistry().LayoutReports(); len(got) != 0 {
+		t.Errorf("NewRegistry().LayoutReports() = %d reports, want 0", len(got))
+	}
+	var verr *ValidationError
+	if _, err := NewRegistry().LayoutReport(reflect.TypeFor[LayoutDevice]()); !errors.As(err, &verr) {
+		t.Errorf("Registry.LayoutReport() error = %v, want ValidationError", err)
+	}
+}
@@ -0,0 +1,174 @@
+package cgocopy2
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// Lazy is a struct field type that defers copying a C object until it is
+// first read. At Copy time only the C pointer is recorded; Get performs the
+// registered Copy[T] on first use and memoizes the result.
+//
+// The C field must be a pointer to a struct registered for T, in the same
+// registry as the enclosing type (Get resolves T there). The pointed-to
+// C memory must stay valid until the value has been loaded or Release has
+// been called: Lazy never frees or pins C memory, it only reads it on Get.
+//
+// Copies of a Lazy value (e.g. copies of the enclosing Go struct) share the
+// same state, so a value loaded through one copy is visible through all.
+// All methods are safe for concurrent use.
+//
+// Example:
+//
+//	type Document struct {
+//	    ID      int32
+//	    Body    cgocopy2.Lazy[Body] // large, rarely read
+//	}
+//
+//	doc, _ := cgocopy2.Copy[Document](cDoc)
+//	body, err := doc.Body.Get() // copies Body from C now
+type Lazy[T any] struct {
+	ref *lazyRef[T]
+}
+
+// lazyRef holds the shared state behind a Lazy value.
+type lazyRef[T any] struct {
+	mu       sync.Mutex
+	registry *Registry
+	cPtr     unsafe.Pointer
+	value    *T
+	released bool
+}
+
+// NewLazy returns a Lazy referring to the C struct at cPtr, copied with the
+// global registry. A nil cPtr yields a Lazy whose Get returns (nil, nil).
+func NewLazy[T any](cPtr unsafe.Pointer) Lazy[T] {
+	return NewLazyWith[T](globalRegistry, cPtr)
+}
+
+// NewLazyWith is like NewLazy but copies T, and every nested type, with reg.
+func NewLazyWith[T any](reg *Registry, cPtr unsafe.Pointer) Lazy[T] {
+	var l Lazy[T]
+	l.bindC(cPtr, reg)
+	return l
+}
+
+// Get returns the copied value, copying it from C on the first call.
+// It returns (nil, nil) if the C pointer was NULL, and ErrReleased if the
+// value was never loaded before Release.
+//
+// Copy errors are not memoized; a later Get retries the copy.
+func (l Lazy[T]) Get() (*T, error) {
+	if l.ref == nil {
+		return nil, nil
+	}
+
+	l.ref.mu.Lock()
+	defer l.ref.mu.Unlock()
+
+	if l.ref.value != nil {
+		return l.ref.value, nil
+	}
+	if l.ref.released {
+		return nil, ErrReleased
+	}
+
+	value, err := CopyWith[T](l.ref.registry, l.ref.cPtr)
+	if err != nil {
+		return nil, err
+	}
+	l.ref.value = &value
+	return l.ref.value, nil
+}
+
+// Loaded reports whether the value has been copied from C.
+func (l Lazy[T]) Loaded() bool {
+	if l.ref == nil {
+		return false
+	}
+
+	l.ref.mu.Lock()
+	defer l.ref.mu.Unlock()
+	return l.ref.value != nil
+}
+
+// Invalidate discards the memoized value so the next Get copies from C
+// again. After Release, Invalidate makes the value unavailable.
+func (l Lazy[T]) Invalidate() {
+	if l.ref == nil {
+		return
+	}
+
+	l.ref.mu.Lock()
+	defer l.ref.mu.Unlock()
+	l.ref.value = nil
+}
+
+// Release declares that the C memory may no longer be read (e.g. because it
+// is about to be freed). A value already loaded stays available; otherwise
+// Get returns ErrReleased.
+func (l Lazy[T]) Release() {
+	if l.ref == nil {
+		return
+	}
+
+	l.ref.mu.Lock()
+	defer l.ref.mu.Unlock()
+	l.ref.cPtr = nil
+	l.ref.released = true
+}
+
+// Pointer returns the C pointer recorded at copy time, or nil after Release.
+func (l Lazy[T]) Pointer() unsafe.Pointer {
+	if l.ref == nil {
+		return nil
+	}
+
+	l.ref.mu.Lock()
+	defer l.ref.mu.Unlock()
+	return l.ref.cPtr
+}
+
+// lazyField is implemented by *Lazy[T] so the reflection-based copy can
+// recognize and bind Lazy fields without knowing T.
+type lazyField interface {
+	bindC(cPtr unsafe.Pointer, registry *Registry)
+	lazyElem() reflect.Type
+	Pointer() unsafe.Pointer
+}
+
+var lazyFieldType = reflect.TypeOf((*lazyField)(nil)).Elem()
+
+func (l *Lazy[T]) bindC(cPtr unsafe.Pointer, registry *Registry) {
+	if cPtr == nil {
+		l.ref = nil
+		return
+	}
+	l.ref = &lazyRef[T]{registry: registry, cPtr: cPtr}
+}
+
+func (l *Lazy[T]) lazyElem() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// isLazyType reports whether t is an instantiation of Lazy.
+func isLazyType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(lazyFieldType)
+}
+
+// lazyElemType returns T for a Lazy[T] type.
+func lazyElemType(t reflect.Type) reflect.Type {
+	return reflect.Zero(reflect.PointerTo(t)).Interface().(lazyField).lazyElem()
+}
+
+// copyLazy binds a Lazy field to the C pointer stored at cPtr, to be copied
+// later with registry.
+func copyLazy(goField reflect.Value, cPtr unsafe.Pointer, registry *Registry) error {
+	lazy, ok := goField.Addr().Interface().(lazyField)
+	if !ok {
+		return ErrInvalidType
+	}
+	lazy.bindC(*(*unsafe.Pointer)(cPtr), registry)
+	return nil
+}
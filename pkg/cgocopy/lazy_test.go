@@ -0,0 +1,204 @@
+package cgocopy2
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+type LazyBody struct {
+	Size  int32
+	Words int32
+}
+
+type LazyDocument struct {
+	ID   int32
+	Body Lazy[LazyBody]
+}
+
+type cLazyDocument struct {
+	ID   int32
+	Body *LazyBody
+}
+
+var lazyDocumentInfo = mirrorCInfo[cLazyDocument]("LazyDocument", "id int32", "body struct")
+
+func TestLazy_DefersCopyUntilGet(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, Precompile[LazyBody]())
+	mustPrecompile(t, PrecompileWithC[LazyDocument](lazyDocumentInfo))
+
+	body := LazyBody{Size: 100, Words: 20}
+	c := cLazyDocument{ID: 1, Body: &body}
+
+	doc, err := Copy[LazyDocument](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if doc.Body.Loaded() {
+		t.Fatal("Body loaded during Copy")
+	}
+
+	// C memory changes before first access are visible
+	body.Words = 21
+
+	got, err := doc.Body.Get()
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Size != 100 || got.Words != 21 {
+		t.Errorf("Get() = %+v, want {100 21}", *got)
+	}
+
+	// Memoized: later C changes are not seen until Invalidate
+	body.Words = 22
+	again, _ := doc.Body.Get()
+	if again != got || again.Words != 21 {
+		t.Errorf("Get() after load = %+v, want memoized value", *again)
+	}
+
+	doc.Body.Invalidate()
+	fresh, err := doc.Body.Get()
+	if err != nil {
+		t.Fatalf("Get() after Invalidate error = %v", err)
+	}
+	if fresh.Words != 22 {
+		t.Errorf("Get() after Invalidate Words = %d, want 22", fresh.Words)
+	}
+}
+
+func TestLazy_NULL(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, Precompile[LazyBody]())
+	mustPrecompile(t, PrecompileWithC[LazyDocument](lazyDocumentInfo))
+
+	c := cLazyDocument{ID: 1}
+	doc, err := Copy[LazyDocument](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	got, err := doc.Body.Get()
+	if got != nil || err != nil {
+		t.Errorf("Get() = %v, %v, want nil, nil", got, err)
+	}
+	if doc.Body.Pointer() != nil {
+		t.Error("Pointer() != nil for NULL C pointer")
+	}
+}
+
+func TestLazy_Release(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, Precompile[LazyBody]())
+
+	body := LazyBody{Size: 5}
+	loaded := NewLazy[LazyBody](unsafe.Pointer(&body))
+	if _, err := loaded.Get(); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	loaded.Release()
+	if got, err := loaded.Get(); err != nil || got.Size != 5 {
+		t.Errorf("Get() after Release = %v, %v, want memoized value", got, err)
+	}
+
+	unloaded := NewLazy[LazyBody](unsafe.Pointer(&body))
+	unloaded.Release()
+	if _, err := unloaded.Get(); !errors.Is(err, ErrReleased) {
+		t.Errorf("Get() error = %v, want ErrReleased", err)
+	}
+	if unloaded.Pointer() != nil {
+		t.Error("Pointer() != nil after Release")
+	}
+}
+
+func TestLazy_SharedAcrossCopies(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, Precompile[LazyBody]())
+
+	body := LazyBody{Size: 7}
+	lazy := NewLazy[LazyBody](unsafe.Pointer(&body))
+	copyOfLazy := lazy
+
+	var wg sync.WaitGroup
+	results := make([]*LazyBody, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = copyOfLazy.Get()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		if r != results[0] {
+			t.Errorf("results[%d] = %p, want %p (copied once)", i, r, results[0])
+		}
+	}
+	if !lazy.Loaded() {
+		t.Error("Loaded() = false on the original after loading through a copy")
+	}
+}
+
+func TestLazy_NotRegistered(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	body := LazyBody{}
+	lazy := NewLazy[LazyBody](unsafe.Pointer(&body))
+	if _, err := lazy.Get(); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Get() error = %v, want ErrNotRegistered", err)
+	}
+}
+
+func TestLazy_RequiresCPointer(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	var c cLazyDocument
+	err := PrecompileWithC[LazyDocument](CStructInfo{
+		Name: "LazyDocument",
+		Size: unsafe.Sizeof(c),
+		Fields: []CFieldInfo{
+			{Name: "id", Type: "int32", Offset: 0, Size: 4},
+			{Name: "body", Type: "int64", Offset: 8, Size: 8},
+		},
+	})
+	if err == nil {
+		t.Error("PrecompileWithC() error = nil, want error for non-pointer C field")
+	}
+}
+
+func TestUpdate_LazyRebindsOnPointerChange(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, Precompile[LazyBody]())
+	mustPrecompile(t, PrecompileWithC[LazyDocument](lazyDocumentInfo))
+
+	first := LazyBody{Size: 1}
+	c := cLazyDocument{ID: 1, Body: &first}
+	doc, err := Copy[LazyDocument](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	changed, err := Update(&doc, unsafe.Pointer(&c))
+	if err != nil || len(changed) != 0 {
+		t.Errorf("Update() = %v, %v, want no changes", changed, err)
+	}
+
+	second := LazyBody{Size: 2}
+	c.Body = &second
+	changed, err = Update(&doc, unsafe.Pointer(&c))
+	if err != nil || len(changed) != 1 || changed[0] != "Body" {
+		t.Fatalf("Update() = %v, %v, want [Body]", changed, err)
+	}
+	if got, _ := doc.Body.Get(); got.Size != 2 {
+		t.Errorf("Body.Size = %d, want 2", got.Size)
+	}
+}
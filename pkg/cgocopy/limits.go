@@ -0,0 +1,106 @@
+package cgocopy2
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// Limits bounds the work a single copy may do when reading C memory.
+// They guard against corrupt or hostile data such as garbage lengths or
+// unterminated strings. A zero field means "no limit".
+//
+// Limits are resolved per field from three levels, highest precedence first:
+// the per-call limits passed to CopyWithLimits, the per-type limits set with
+// SetTypeLimits, and the global defaults set with SetDefaultLimits. Type
+// limits apply to the fields of that type wherever it is copied, as the
+// top-level type or nested in another; a nested type's zero fields fall back
+// to the limits of the struct enclosing it. MaxDepth and MaxBytes always
+// count from the start of the copy.
+//
+// Lengths that cannot be allocated at all, such as a negative slice length,
+// fail with ErrLimitExceeded even when no limits are set.
+//
+// Exceeding a limit fails the copy with a CopyError wrapping ErrLimitExceeded.
+type Limits struct {
+	// MaxStringLen is the maximum length in bytes of a single C string.
+	// The NUL terminator is never searched for beyond this length.
+	MaxStringLen int `json:"maxStringLen,omitempty"`
+
+	// MaxElements is the maximum number of elements in a single slice or map.
+	MaxElements int `json:"maxElements,omitempty"`
+
+	// MaxDepth is the maximum struct nesting depth, counting the top-level
+	// struct as 1 and following nested fields, array elements and pointers.
+	MaxDepth int `json:"maxDepth,omitempty"`
+
+	// MaxBytes is the total allocation budget for one copy, covering string
+	// data, slice backing arrays, map entries and pointer targets.
+	MaxBytes int `json:"maxBytes,omitempty"`
+}
+
+// merge returns l with its zero fields filled in from fallback.
+func (l Limits) merge(fallback Limits) Limits {
+	if l.MaxStringLen == 0 {
+		l.MaxStringLen = fallback.MaxStringLen
+	}
+	if l.MaxElements == 0 {
+		l.MaxElements = fallback.MaxElements
+	}
+	if l.MaxDepth == 0 {
+		l.MaxDepth = fallback.MaxDepth
+	}
+	if l.MaxBytes == 0 {
+		l.MaxBytes = fallback.MaxBytes
+	}
+	return l
+}
+
+// defaultLimits holds the global limits applied to every copy.
+var defaultLimits atomic.Pointer[Limits]
+
+// SetDefaultLimits sets the global resource limits used by Copy and friends.
+// Pass the zero Limits to remove all global limits.
+//
+// Example:
+//
+//	func init() {
+//	    cgocopy2.SetDefaultLimits(cgocopy2.Limits{
+//	        MaxStringLen: 1 << 20,
+//	        MaxElements:  1 << 16,
+//	        MaxDepth:     32,
+//	    })
+//	}
+func SetDefaultLimits(limits Limits) {
+	defaultLimits.Store(&limits)
+}
+
+// DefaultLimits returns the global resource limits.
+func DefaultLimits() Limits {
+	if limits := defaultLimits.Load(); limits != nil {
+		return *limits
+	}
+	return Limits{}
+}
+
+// SetTypeLimits sets the resource limits applied when copying type T.
+// T must already be registered, and the registry not yet sealed.
+func SetTypeLimits[T any](limits Limits) error {
+	return globalRegistry.SetTypeLimits(reflect.TypeFor[T](), limits)
+}
+
+// SetTypeLimits sets the resource limits applied when copying goType with
+// r. It is the registry-scoped form of SetTypeLimits[T]; pointer types are
+// dereferenced.
+func (r *Registry) SetTypeLimits(goType reflect.Type, limits Limits) error {
+	if goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+
+	if r.IsSealed() {
+		return newRegistrationError(goType, "cannot set limits in a sealed registry", ErrRegistrySealed)
+	}
+	if !r.SetLimits(goType, limits) {
+		return newRegistrationError(goType, "cannot set limits on unregistered type", ErrNotRegistered)
+	}
+	return nil
+}
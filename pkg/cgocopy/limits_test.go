@@ -0,0 +1,239 @@
+package cgocopy2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type LimitedRecord struct {
+	Name  string
+	Items []int32
+}
+
+// cLimitedRecord mirrors LimitedRecord with C-style char* and {data, len} slice.
+type cLimitedRecord struct {
+	Name  *byte
+	Items struct {
+		data unsafe.Pointer
+		len  int
+	}
+}
+
+func newCLimitedRecord(name string, items []int32) cLimitedRecord {
+	var c cLimitedRecord
+	c.Name = cString(name)
+	if len(items) > 0 {
+		c.Items.data = unsafe.Pointer(&items[0])
+		c.Items.len = len(items)
+	}
+	return c
+}
+
+func limitedRecordInfo() CStructInfo {
+	return mirrorCInfo[cLimitedRecord]("LimitedRecord", "name string", "items struct")
+}
+
+func TestLimits_Merge(t *testing.T) {
+	call := Limits{MaxStringLen: 10}
+	typ := Limits{MaxStringLen: 20, MaxElements: 5}
+	global := Limits{MaxElements: 50, MaxDepth: 3, MaxBytes: 1024}
+
+	got := call.merge(typ).merge(global)
+	want := Limits{MaxStringLen: 10, MaxElements: 5, MaxDepth: 3, MaxBytes: 1024}
+	if got != want {
+		t.Errorf("merge() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCopyWithLimits_MaxStringLen(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	mustPrecompile(t, PrecompileWithC[LimitedRecord](limitedRecordInfo()))
+
+	c := newCLimitedRecord("a string that is far too long", nil)
+
+	_, err := CopyWithLimits[LimitedRecord](unsafe.Pointer(&c), Limits{MaxStringLen: 8})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("CopyWithLimits() error = %v, want ErrLimitExceeded", err)
+	}
+
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) || copyErr.FieldName != "Name" {
+		t.Errorf("error should be a CopyError for field Name, got %v", err)
+	}
+
+	// Exactly at the limit is fine
+	c = newCLimitedRecord("12345678", nil)
+	result, err := CopyWithLimits[LimitedRecord](unsafe.Pointer(&c), Limits{MaxStringLen: 8})
+	if err != nil {
+		t.Fatalf("CopyWithLimits() error = %v", err)
+	}
+	if result.Name != "12345678" {
+		t.Errorf("Name = %q, want %q", result.Name, "12345678")
+	}
+}
+
+func TestCopyWithLimits_MaxElements(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	mustPrecompile(t, PrecompileWithC[LimitedRecord](limitedRecordInfo()))
+
+	c := newCLimitedRecord("x", []int32{1, 2, 3, 4})
+
+	_, err := CopyWithLimits[LimitedRecord](unsafe.Pointer(&c), Limits{MaxElements: 3})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("CopyWithLimits() error = %v, want ErrLimitExceeded", err)
+	}
+}
+
+func TestCopyWithLimits_MaxBytes(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	mustPrecompile(t, PrecompileWithC[LimitedRecord](limitedRecordInfo()))
+
+	// 4 bytes of string + 16 bytes of slice data
+	c := newCLimitedRecord("abcd", []int32{1, 2, 3, 4})
+
+	if _, err := CopyWithLimits[LimitedRecord](unsafe.Pointer(&c), Limits{MaxBytes: 20}); err != nil {
+		t.Errorf("CopyWithLimits(MaxBytes: 20) error = %v, want nil", err)
+	}
+
+	_, err := CopyWithLimits[LimitedRecord](unsafe.Pointer(&c), Limits{MaxBytes: 19})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("CopyWithLimits(MaxBytes: 19) error = %v, want ErrLimitExceeded", err)
+	}
+}
+
+func TestCopyWithLimits_MaxDepth(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Leaf struct {
+		Value int32
+	}
+	type Middle struct {
+		Leaf Leaf
+	}
+	type Root struct {
+		Middle Middle
+	}
+
+	Precompile[Leaf]()
+	Precompile[Middle]()
+	Precompile[Root]()
+
+	c := Root{Middle: Middle{Leaf: Leaf{Value: 1}}}
+
+	if _, err := CopyWithLimits[Root](unsafe.Pointer(&c), Limits{MaxDepth: 3}); err != nil {
+		t.Errorf("CopyWithLimits(MaxDepth: 3) error = %v, want nil", err)
+	}
+
+	_, err := CopyWithLimits[Root](unsafe.Pointer(&c), Limits{MaxDepth: 2})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("CopyWithLimits(MaxDepth: 2) error = %v, want ErrLimitExceeded", err)
+	}
+}
+
+func TestSetTypeLimits(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := SetTypeLimits[LimitedRecord](Limits{MaxStringLen: 4}); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("SetTypeLimits() on unregistered type error = %v, want ErrNotRegistered", err)
+	}
+
+	mustPrecompile(t, PrecompileWithC[LimitedRecord](limitedRecordInfo()))
+	if err := SetTypeLimits[LimitedRecord](Limits{MaxStringLen: 4}); err != nil {
+		t.Fatalf("SetTypeLimits() error = %v", err)
+	}
+
+	c := newCLimitedRecord("too long", nil)
+
+	_, err := Copy[LimitedRecord](unsafe.Pointer(&c))
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Copy() error = %v, want ErrLimitExceeded from type limits", err)
+	}
+
+	// Per-call limits take precedence over type limits
+	if _, err := CopyWithLimits[LimitedRecord](unsafe.Pointer(&c), Limits{MaxStringLen: 64}); err != nil {
+		t.Errorf("CopyWithLimits() error = %v, want nil", err)
+	}
+}
+
+func TestSetTypeLimits_NestedType(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Envelope struct {
+		Label  string
+		Record LimitedRecord
+	}
+	type cEnvelope struct {
+		Label  *byte
+		Record cLimitedRecord
+	}
+
+	mustPrecompile(t, PrecompileWithC[LimitedRecord](limitedRecordInfo()))
+	mustPrecompile(t, PrecompileWithC[Envelope](mirrorCInfo[cEnvelope]("Envelope", "label string", "record LimitedRecord")))
+	if err := SetTypeLimits[LimitedRecord](Limits{MaxStringLen: 4}); err != nil {
+		t.Fatalf("SetTypeLimits() error = %v", err)
+	}
+
+	// The nested type's limits cover its own fields only
+	ce := cEnvelope{Label: cString("a long label"), Record: newCLimitedRecord("abcd", nil)}
+	if _, err := Copy[Envelope](unsafe.Pointer(&ce)); err != nil {
+		t.Fatalf("Copy() error = %v, want nil", err)
+	}
+
+	ce.Record = newCLimitedRecord("too long", nil)
+	_, err := Copy[Envelope](unsafe.Pointer(&ce))
+	var copyErr *CopyError
+	if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &copyErr) || copyErr.FieldName != "Record.Name" {
+		t.Errorf("Copy() error = %v, want ErrLimitExceeded at Record.Name", err)
+	}
+
+	// Per-call limits still take precedence
+	if _, err := CopyWithLimits[Envelope](unsafe.Pointer(&ce), Limits{MaxStringLen: 64}); err != nil {
+		t.Errorf("CopyWithLimits() error = %v, want nil", err)
+	}
+}
+
+func TestCopy_RejectsImpossibleLengths(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	mustPrecompile(t, PrecompileWithC[LimitedRecord](limitedRecordInfo()))
+
+	// No limits are set: these lengths would panic in reflect.MakeSlice
+	for _, length := range []int{-1, 1 << 62} {
+		c := newCLimitedRecord("x", []int32{1})
+		c.Items.len = length
+		if _, err := Copy[LimitedRecord](unsafe.Pointer(&c)); !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("Copy() with length %d error = %v, want ErrLimitExceeded", length, err)
+		}
+	}
+}
+
+func TestSetDefaultLimits(t *testing.T) {
+	Reset()
+	defer Reset()
+	defer SetDefaultLimits(Limits{})
+
+	mustPrecompile(t, PrecompileWithC[LimitedRecord](limitedRecordInfo()))
+
+	SetDefaultLimits(Limits{MaxStringLen: 2})
+	if got := DefaultLimits(); got.MaxStringLen != 2 {
+		t.Errorf("DefaultLimits().MaxStringLen = %d, want 2", got.MaxStringLen)
+	}
+
+	c := newCLimitedRecord("abc", nil)
+	_, err := Copy[LimitedRecord](unsafe.Pointer(&c))
+	if err == nil || !strings.Contains(err.Error(), "MaxStringLen") {
+		t.Errorf("Copy() error = %v, want MaxStringLen violation", err)
+	}
+}
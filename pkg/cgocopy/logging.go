@@ -0,0 +1,177 @@
+package cgocopy2
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime/trace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// debugLogging holds the loggers installed with SetDebugLogger and
+// SetTypeDebugLogger.
+var debugLogging struct {
+	// enabled is true while any logger is installed, so copies skip the
+	// logger lookup entirely otherwise.
+	enabled atomic.Bool
+
+	mu      sync.Mutex
+	global  atomic.Pointer[slog.Logger]
+	byType  sync.Map // reflect.Type -> *slog.Logger
+	perType int      // number of byType entries, guarded by mu
+}
+
+// SetDebugLogger logs every copied field of every type to logger at debug
+// level: its field path, C offset, C type and decoded value. Pass nil to
+// stop logging types without a logger of their own (see SetTypeDebugLogger).
+//
+// Logging covers Copy, CopyWith, CopyWithLimits, CopyByCName, the elements
+// of bulk copies, Projection.Copy, Update (which also logs whether each field
+// changed) and CopyToMap (whose paths are C field names). It is meant for debugging wrong field values and
+// is expensive while enabled; with no logger installed copies pay nothing.
+//
+// Example:
+//
+//	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+//	cgocopy2.SetDebugLogger(slog.New(handler))
+func SetDebugLogger(logger *slog.Logger) {
+	debugLogging.mu.Lock()
+	defer debugLogging.mu.Unlock()
+
+	debugLogging.global.Store(logger)
+	debugLogging.enabled.Store(logger != nil || debugLogging.perType > 0)
+}
+
+// SetTypeDebugLogger logs the copied fields of T to logger at debug level,
+// overriding the logger set with SetDebugLogger. It applies wherever T is
+// copied, including as a nested struct of another type; nested types without
+// a logger of their own log to the logger of the enclosing struct. Pass nil
+// to remove it.
+func SetTypeDebugLogger[T any](logger *slog.Logger) {
+	debugLogging.mu.Lock()
+	defer debugLogging.mu.Unlock()
+
+	goType := reflect.TypeFor[T]()
+	_, had := debugLogging.byType.Load(goType)
+	switch {
+	case logger != nil && !had:
+		debugLogging.perType++
+	case logger == nil && had:
+		debugLogging.perType--
+	}
+
+	if logger == nil {
+		debugLogging.byType.Delete(goType)
+	} else {
+		debugLogging.byType.Store(goType, logger)
+	}
+	debugLogging.enabled.Store(debugLogging.global.Load() != nil || debugLogging.perType > 0)
+}
+
+// debugLogger returns the logger for copies of metadata's type, tagged with
+// the type name, or nil if its debug level is disabled.
+func debugLogger(metadata *StructMetadata) *slog.Logger {
+	if !debugLogging.enabled.Load() {
+		return nil
+	}
+
+	logger := debugLogging.global.Load()
+	if typeLogger, ok := debugLogging.byType.Load(metadata.GoType); ok {
+		logger = typeLogger.(*slog.Logger)
+	}
+	return taggedLogger(logger, metadata.TypeName)
+}
+
+// typeDebugLogger returns the logger installed with SetTypeDebugLogger for a
+// nested struct of metadata's type, tagged with the type name. ok is false
+// when the type has no logger of its own and inherits the enclosing one.
+func typeDebugLogger(metadata *StructMetadata) (logger *slog.Logger, ok bool) {
+	typeLogger, ok := debugLogging.byType.Load(metadata.GoType)
+	if !ok {
+		return nil, false
+	}
+	return taggedLogger(typeLogger.(*slog.Logger), metadata.TypeName), true
+}
+
+// taggedLogger tags logger with typeName, or returns nil if logger is nil or
+// its debug level is disabled.
+func taggedLogger(logger *slog.Logger, typeName string) *slog.Logger {
+	if logger == nil || !logger.Enabled(context.Background(), slog.LevelDebug) {
+		return nil
+	}
+	return logger.With(slog.String("type", typeName))
+}
+
+// startLogging enables field logging for a copy of metadata's type. Field
+// paths are tracked whenever any logger is installed, since nested types may
+// log to loggers of their own.
+func (s *copyState) startLogging(metadata *StructMetadata) {
+	if !debugLogging.enabled.Load() {
+		return
+	}
+	s.logging = true
+	s.logger = debugLogger(metadata)
+}
+
+// copyLoggedField copies a struct field, and logs it when debug logging is
+// enabled. Nested structs log their own fields under the extended path.
+func (s *copyState) copyLoggedField(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) error {
+	if !s.logging {
+		return s.copyField(goField, cPtr, field)
+	}
+
+	s.path = append(s.path, field.Name)
+	defer func() { s.path = s.path[:len(s.path)-1] }()
+
+	if err := s.copyField(goField, cPtr, field); err != nil {
+		return err
+	}
+	if field.Type != FieldTypeStruct {
+		s.logField("cgocopy: copied field", strings.Join(s.path, "."), field.Offset, field.CType, goField)
+	}
+	return nil
+}
+
+// logField logs one copied field to the current logger, if any. Extra
+// attributes follow the common ones.
+func (s *copyState) logField(msg, path string, cOffset uintptr, cType string, value reflect.Value, extra ...slog.Attr) {
+	if s.logger == nil {
+		return
+	}
+	attrs := append([]slog.Attr{
+		slog.String("path", path),
+		slog.Uint64("c_offset", uint64(cOffset)),
+		slog.String("c_type", cType),
+		slog.Any("value", loggedValue(value)),
+	}, extra...)
+	s.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, attrs...)
+}
+
+// loggedValue returns the value of a copied field for logging.
+func loggedValue(v reflect.Value) any {
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return fmt.Sprint(v)
+}
+
+// traceRegion starts a runtime/trace region for a copy of the named type,
+// named "cgocopy.<op> <type>". It returns nil when tracing is off; end it
+// with endTraceRegion.
+func traceRegion(ctx context.Context, op, typeName string) *trace.Region {
+	if !trace.IsEnabled() {
+		return nil
+	}
+	return trace.StartRegion(ctx, "cgocopy."+op+" "+typeName)
+}
+
+// endTraceRegion ends a region started by traceRegion.
+func endTraceRegion(region *trace.Region) {
+	if region != nil {
+		region.End()
+	}
+}
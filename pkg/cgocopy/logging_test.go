@@ -0,0 +1,328 @@
+package cgocopy2
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"runtime/trace"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// newDebugLogger returns a JSON logger writing into buf at level.
+func newDebugLogger(buf *bytes.Buffer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: level}))
+}
+
+// loggedFields decodes the JSON log records in buf, keyed by field path.
+func loggedFields(t *testing.T, buf *bytes.Buffer) map[string]map[string]any {
+	t.Helper()
+
+	fields := make(map[string]map[string]any)
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid log record %q: %v", scanner.Text(), err)
+		}
+		fields[record["path"].(string)] = record
+	}
+	return fields
+}
+
+func TestSetDebugLogger_LogsFields(t *testing.T) {
+	Reset()
+	defer Reset()
+	defer SetDebugLogger(nil)
+
+	if err := Precompile[CopySimple](); err != nil {
+		t.Fatalf("Precompile[CopySimple]() error = %v", err)
+	}
+	if err := Precompile[CopyNested](); err != nil {
+		t.Fatalf("Precompile[CopyNested]() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	SetDebugLogger(newDebugLogger(&buf, slog.LevelDebug))
+
+	c := CopyNested{ID: 1, Profile: CopySimple{ID: 2, Count: 50}}
+	if _, err := Copy[CopyNested](unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	fields := loggedFields(t, &buf)
+	for _, path := range []string{"ID", "Profile.ID", "Profile.Count", "Profile.Score", "Profile.Flag"} {
+		if _, ok := fields[path]; !ok {
+			t.Errorf("no log record for %s, got %v", path, fields)
+		}
+	}
+	if _, ok := fields["Profile"]; ok {
+		t.Error("nested struct logged as a field, want only its leaf fields")
+	}
+
+	count := fields["Profile.Count"]
+	if count["msg"] != "cgocopy: copied field" || count["level"] != "DEBUG" {
+		t.Errorf("record = %v", count)
+	}
+	if count["type"] != GetMetadata[CopyNested]().TypeName {
+		t.Errorf("type = %v, want %s", count["type"], GetMetadata[CopyNested]().TypeName)
+	}
+	// Offsets are relative to the enclosing C struct
+	if got := count["c_offset"]; got != float64(unsafe.Offsetof(c.Profile.Count)) {
+		t.Errorf("c_offset = %v, want %d", got, unsafe.Offsetof(c.Profile.Count))
+	}
+	if count["value"] != float64(50) {
+		t.Errorf("value = %v, want 50", count["value"])
+	}
+}
+
+func TestSetDebugLogger_Disabled(t *testing.T) {
+	Reset()
+	defer Reset()
+	defer SetDebugLogger(nil)
+
+	if err := Precompile[CopySimple](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+	c := CopySimple{ID: 1}
+
+	// A logger above debug level logs nothing
+	var buf bytes.Buffer
+	SetDebugLogger(newDebugLogger(&buf, slog.LevelInfo))
+	if _, err := Copy[CopySimple](unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("logged at info level: %s", buf.String())
+	}
+
+	// Removing the logger stops logging
+	SetDebugLogger(newDebugLogger(&buf, slog.LevelDebug))
+	SetDebugLogger(nil)
+	if _, err := Copy[CopySimple](unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("logged after SetDebugLogger(nil): %s", buf.String())
+	}
+}
+
+func TestSetTypeDebugLogger(t *testing.T) {
+	Reset()
+	defer Reset()
+	defer SetDebugLogger(nil)
+	defer SetTypeDebugLogger[CopySimple](nil)
+
+	if err := Precompile[CopySimple](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+	if err := Precompile[CopyArrays](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	var global, simple bytes.Buffer
+	SetDebugLogger(newDebugLogger(&global, slog.LevelDebug))
+	SetTypeDebugLogger[CopySimple](newDebugLogger(&simple, slog.LevelDebug))
+
+	s := CopySimple{ID: 1}
+	a := CopyArrays{Values: [3]int{1, 2, 3}}
+	if _, err := Copy[CopySimple](unsafe.Pointer(&s)); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if _, err := Copy[CopyArrays](unsafe.Pointer(&a)); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	if !strings.Contains(simple.String(), `"path":"Count"`) || strings.Contains(simple.String(), "Values") {
+		t.Errorf("type logger output = %s, want only CopySimple fields", simple.String())
+	}
+	if !strings.Contains(global.String(), `"path":"Values"`) || strings.Contains(global.String(), `"path":"Count"`) {
+		t.Errorf("global logger output = %s, want only CopyArrays fields", global.String())
+	}
+
+	// The type logger alone keeps logging enabled
+	SetDebugLogger(nil)
+	simple.Reset()
+	if _, err := Copy[CopySimple](unsafe.Pointer(&s)); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if simple.Len() == 0 {
+		t.Error("type logger not used without a global logger")
+	}
+
+	SetTypeDebugLogger[CopySimple](nil)
+	simple.Reset()
+	if _, err := Copy[CopySimple](unsafe.Pointer(&s)); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if simple.Len() != 0 {
+		t.Errorf("logged after SetTypeDebugLogger(nil): %s", simple.String())
+	}
+}
+
+func TestSetTypeDebugLogger_NestedType(t *testing.T) {
+	Reset()
+	defer Reset()
+	defer SetDebugLogger(nil)
+	defer SetTypeDebugLogger[CopySimple](nil)
+
+	if err := Precompile[CopySimple](); err != nil {
+		t.Fatalf("Precompile[CopySimple]() error = %v", err)
+	}
+	if err := Precompile[CopyNested](); err != nil {
+		t.Fatalf("Precompile[CopyNested]() error = %v", err)
+	}
+
+	var global, simple bytes.Buffer
+	SetTypeDebugLogger[CopySimple](newDebugLogger(&simple, slog.LevelDebug))
+
+	c := CopyNested{ID: 1, Profile: CopySimple{ID: 2, Count: 50}}
+
+	// A nested type logs to its own logger, under the full path
+	if _, err := Copy[CopyNested](unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	fields := loggedFields(t, &simple)
+	count, ok := fields["Profile.Count"]
+	if !ok || len(fields) != 4 {
+		t.Fatalf("type logger records = %v, want the 4 Profile fields", fields)
+	}
+	if count["type"] != GetMetadata[CopySimple]().TypeName {
+		t.Errorf("type = %v, want %s", count["type"], GetMetadata[CopySimple]().TypeName)
+	}
+
+	// The enclosing type keeps the global logger
+	SetDebugLogger(newDebugLogger(&global, slog.LevelDebug))
+	if _, err := Copy[CopyNested](unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	fields = loggedFields(t, &global)
+	if _, ok := fields["ID"]; !ok || len(fields) != 1 {
+		t.Errorf("global logger records = %v, want only ID", fields)
+	}
+	if len(loggedFields(t, &simple)) != 4 {
+		t.Error("nested fields not logged to the type logger with a global logger installed")
+	}
+}
+
+func TestSetDebugLogger_ProjectionAndUpdate(t *testing.T) {
+	Reset()
+	defer Reset()
+	defer SetDebugLogger(nil)
+
+	if err := Precompile[CopySimple](); err != nil {
+		t.Fatalf("Precompile[CopySimple]() error = %v", err)
+	}
+	if err := Precompile[CopyNested](); err != nil {
+		t.Fatalf("Precompile[CopyNested]() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	SetDebugLogger(newDebugLogger(&buf, slog.LevelDebug))
+
+	c := CopyNested{ID: 1, Profile: CopySimple{ID: 2, Count: 50}}
+	p, err := NewProjection[CopyNested]("ID", "Profile.Count")
+	if err != nil {
+		t.Fatalf("NewProjection() error = %v", err)
+	}
+	if _, err := p.Copy(unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("Projection.Copy() error = %v", err)
+	}
+	fields := loggedFields(t, &buf)
+	if _, ok := fields["Profile.Count"]; !ok || len(fields) != 2 {
+		t.Errorf("projection records = %v, want ID and Profile.Count", fields)
+	}
+
+	dst := CopyNested{ID: 1, Profile: CopySimple{ID: 2}}
+	if _, err := Update(&dst, unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	fields = loggedFields(t, &buf)
+	count, ok := fields["Profile.Count"]
+	if !ok || len(fields) != 5 {
+		t.Fatalf("update records = %v, want every leaf field", fields)
+	}
+	if count["msg"] != "cgocopy: updated field" || count["changed"] != true || count["value"] != float64(50) {
+		t.Errorf("Profile.Count record = %v", count)
+	}
+	if fields["ID"]["changed"] != false {
+		t.Errorf("ID record = %v, want changed false", fields["ID"])
+	}
+}
+
+func TestSetDebugLogger_CopyToMap(t *testing.T) {
+	Reset()
+	defer Reset()
+	defer SetDebugLogger(nil)
+	AddCMetadata(dynPointInfo)
+
+	var buf bytes.Buffer
+	SetDebugLogger(newDebugLogger(&buf, slog.LevelDebug))
+
+	c := dynRecord{ID: 42, Name: cString("alice"), Origin: dynPoint{X: 1, Y: 2}}
+	if _, err := CopyToMap(dynRecordInfo(), unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("CopyToMap() error = %v", err)
+	}
+
+	fields := loggedFields(t, &buf)
+	for _, path := range []string{"id", "name", "origin.x", "origin.y", "path"} {
+		if _, ok := fields[path]; !ok {
+			t.Errorf("no log record for %s, got %v", path, fields)
+		}
+	}
+	if _, ok := fields["origin"]; ok {
+		t.Error("nested struct logged as a field, want only its leaf fields")
+	}
+	if name := fields["name"]; name["type"] != "dyn_record" || name["c_type"] != "string" || name["value"] != "alice" {
+		t.Errorf("name record = %v", name)
+	}
+}
+
+func TestCopy_TraceRegions(t *testing.T) {
+	if trace.IsEnabled() {
+		t.Skip("tracing already enabled")
+	}
+	Reset()
+	defer Reset()
+
+	if err := Precompile[CopySimple](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	AddCMetadata(dynPointInfo)
+	p, err := NewProjection[CopySimple]("ID")
+	if err != nil {
+		t.Fatalf("NewProjection() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Fatalf("trace.Start() error = %v", err)
+	}
+	c := CopySimple{ID: 1}
+	var dst CopySimple
+	point := dynPoint{X: 1}
+	_, copyErr := Copy[CopySimple](unsafe.Pointer(&c))
+	_, projectErr := p.Copy(unsafe.Pointer(&c))
+	_, updateErr := Update(&dst, unsafe.Pointer(&c))
+	_, mapErr := CopyToMap(dynPointInfo, unsafe.Pointer(&point))
+	trace.Stop()
+	if err := errors.Join(copyErr, projectErr, updateErr, mapErr); err != nil {
+		t.Fatalf("copy error = %v", err)
+	}
+
+	typeName := GetMetadata[CopySimple]().TypeName
+	for _, want := range []string{
+		"cgocopy.Copy " + typeName,
+		"cgocopy.Projection.Copy " + typeName,
+		"cgocopy.Update " + typeName,
+		"cgocopy.CopyToMap dyn_point",
+	} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("trace has no %q region", want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package cgocopy2
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives one CopyEvent per finished copy of a registered type.
+// Install an implementation with SetMetrics; CopyMetrics is a ready-made
+// one. RecordCopy is called concurrently from every copying goroutine and
+// should not block.
+type Metrics interface {
+	RecordCopy(event CopyEvent)
+}
+
+// CopyEvent describes one finished copy: a Copy, CopyWith, CopyWithLimits,
+// CopyByCName, Update or Projection.Copy call, or one element of a bulk
+// copy (CopyArrayParallel, Each).
+type CopyEvent struct {
+	// TypeName is the package-qualified Go type name.
+	TypeName string
+
+	// BytesRead is the number of C bytes read: the C struct itself (its C
+	// size, inline arrays and nested structs included) plus the string data
+	// (with its NUL), slice and map elements and pointer targets it refers
+	// to. Go allocation sizes play no part.
+	BytesRead int
+
+	// Strings is the number of Go strings allocated.
+	Strings int
+
+	// Duration is the wall time of the copy.
+	Duration time.Duration
+
+	// Err is the error the copy returned, if any.
+	Err error
+}
+
+// metricsHolder boxes the Metrics interface for atomic.Pointer.
+type metricsHolder struct {
+	metrics Metrics
+}
+
+// activeMetrics holds the installed Metrics (nil when disabled).
+var activeMetrics atomic.Pointer[metricsHolder]
+
+// SetMetrics installs m to receive an event for every copy. Pass nil to
+// disable instrumentation; copies then skip all timing and bookkeeping.
+//
+// Example:
+//
+//	metrics := cgocopy2.NewCopyMetrics()
+//	cgocopy2.SetMetrics(metrics)
+//	expvar.Publish("cgocopy", metrics)
+func SetMetrics(m Metrics) {
+	if m == nil {
+		activeMetrics.Store(nil)
+		return
+	}
+	activeMetrics.Store(&metricsHolder{metrics: m})
+}
+
+// loadMetrics returns the installed Metrics, or nil.
+func loadMetrics() Metrics {
+	if holder := activeMetrics.Load(); holder != nil {
+		return holder.metrics
+	}
+	return nil
+}
+
+// recordCopy reports a finished copy to m.
+func recordCopy(m Metrics, metadata *StructMetadata, state *copyState, start time.Time, err error) {
+	m.RecordCopy(CopyEvent{
+		TypeName:  metadata.TypeName,
+		BytesRead: int(metadata.Size) + state.read,
+		Strings:   state.strings,
+		Duration:  time.Since(start),
+		Err:       err,
+	})
+}
+
+// latencyBounds are the upper bounds of the CopyMetrics latency histogram
+// buckets; a final bucket counts slower copies.
+var latencyBounds = []time.Duration{
+	250 * time.Nanosecond,
+	500 * time.Nanosecond,
+	time.Microsecond,
+	2500 * time.Nanosecond,
+	5 * time.Microsecond,
+	10 * time.Microsecond,
+	25 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+}
+
+// CopyMetrics is a Metrics that keeps per-type counters and latency
+// histograms in memory. It implements expvar.Var, so it can be published
+// directly.
+type CopyMetrics struct {
+	types sync.Map // type name -> *typeCounters
+}
+
+// typeCounters are the counters of one type.
+type typeCounters struct {
+	copies    atomic.Int64
+	errors    atomic.Int64
+	bytesRead atomic.Int64
+	strings   atomic.Int64
+	latency   atomic.Int64
+	buckets   []atomic.Int64 // len(latencyBounds)+1
+}
+
+// NewCopyMetrics creates an empty CopyMetrics.
+func NewCopyMetrics() *CopyMetrics {
+	return &CopyMetrics{}
+}
+
+// RecordCopy implements Metrics.
+func (m *CopyMetrics) RecordCopy(event CopyEvent) {
+	counters, ok := m.types.Load(event.TypeName)
+	if !ok {
+		counters, _ = m.types.LoadOrStore(event.TypeName, &typeCounters{
+			buckets: make([]atomic.Int64, len(latencyBounds)+1),
+		})
+	}
+	c := counters.(*typeCounters)
+
+	c.copies.Add(1)
+	if event.Err != nil {
+		c.errors.Add(1)
+	}
+	c.bytesRead.Add(int64(event.BytesRead))
+	c.strings.Add(int64(event.Strings))
+	c.latency.Add(int64(event.Duration))
+
+	bucket := sort.Search(len(latencyBounds), func(i int) bool {
+		return event.Duration <= latencyBounds[i]
+	})
+	c.buckets[bucket].Add(1)
+}
+
+// TypeMetrics is a snapshot of the counters of one type.
+type TypeMetrics struct {
+	// Copies counts finished copies, including failed ones.
+	Copies int64 `json:"copies"`
+
+	// Errors counts failed copies.
+	Errors int64 `json:"errors"`
+
+	// BytesRead is the total number of C bytes read.
+	BytesRead int64 `json:"bytesRead"`
+
+	// Strings is the total number of Go strings allocated.
+	Strings int64 `json:"strings"`
+
+	// TotalLatency is the summed duration of all copies.
+	TotalLatency time.Duration `json:"totalLatencyNs"`
+
+	// Latency is the latency histogram, in increasing bucket order.
+	Latency []LatencyBucket `json:"latency"`
+}
+
+// LatencyBucket counts the copies that took longer than the previous
+// bucket's bound and at most UpperBound. The last bucket has no upper bound
+// and UpperBound 0.
+type LatencyBucket struct {
+	UpperBound time.Duration `json:"leNs"`
+	Count      int64         `json:"count"`
+}
+
+// Snapshot returns the current counters, keyed by package-qualified type
+// name.
+func (m *CopyMetrics) Snapshot() map[string]TypeMetrics {
+	snapshot := make(map[string]TypeMetrics)
+	m.types.Range(func(key, value any) bool {
+		c := value.(*typeCounters)
+		tm := TypeMetrics{
+			Copies:       c.copies.Load(),
+			Errors:       c.errors.Load(),
+			BytesRead:    c.bytesRead.Load(),
+			Strings:      c.strings.Load(),
+			TotalLatency: time.Duration(c.latency.Load()),
+			Latency:      make([]LatencyBucket, len(c.buckets)),
+		}
+		for i := range c.buckets {
+			if i < len(latencyBounds) {
+				tm.Latency[i].UpperBound = latencyBounds[i]
+			}
+			tm.Latency[i].Count = c.buckets[i].Load()
+		}
+		snapshot[key.(string)] = tm
+		return true
+	})
+	return snapshot
+}
+
+// Reset clears all counters.
+func (m *CopyMetrics) Reset() {
+	m.types.Clear()
+}
+
+// String returns the snapshot as JSON, implementing expvar.Var.
+func (m *CopyMetrics) String() string {
+	data, err := json.Marshal(m.Snapshot())
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
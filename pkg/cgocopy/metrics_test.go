@@ -0,0 +1,232 @@
+package cgocopy2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// recordingMetrics collects every event it receives.
+type recordingMetrics struct {
+	mu     sync.Mutex
+	events []CopyEvent
+}
+
+func (m *recordingMetrics) RecordCopy(event CopyEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+}
+
+type MetricsUser struct {
+	ID   int32
+	Name string
+}
+
+// cMetricsUser mirrors MetricsUser with a char* name. Without C metadata
+// the element stride of bulk copies is the Go size, so it is padded to it.
+type cMetricsUser struct {
+	ID   int32
+	Name *byte
+	_    uintptr
+}
+
+func TestCopyEvent_BytesReadCountsCBytes(t *testing.T) {
+	Reset()
+	defer Reset()
+	defer SetMetrics(nil)
+
+	type Account struct {
+		Name  string
+		Tags  []string
+		Score *int32
+	}
+	type cAccount struct {
+		Name *byte
+		Tags struct {
+			data unsafe.Pointer
+			len  int
+		}
+		Score *int32
+	}
+
+	var c cAccount
+	if err := PrecompileWithC[Account](CStructInfo{
+		Name: "account",
+		Size: unsafe.Sizeof(c),
+		Fields: []CFieldInfo{
+			{Name: "name", Type: "string", Offset: unsafe.Offsetof(c.Name), Size: 8, IsPointer: true},
+			{Name: "tags", Type: "struct", Offset: unsafe.Offsetof(c.Tags), Size: unsafe.Sizeof(c.Tags)},
+			{Name: "score", Type: "int32*", Offset: unsafe.Offsetof(c.Score), Size: 8, IsPointer: true},
+		},
+	}); err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+
+	m := &recordingMetrics{}
+	SetMetrics(m)
+
+	tags := []*byte{cString("a"), cString("bc")}
+	score := int32(7)
+	c.Name = cString("alice")
+	c.Tags.data, c.Tags.len = unsafe.Pointer(&tags[0]), len(tags)
+	c.Score = &score
+	got, err := Copy[Account](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if len(got.Tags) != 2 || got.Tags[1] != "bc" {
+		t.Errorf("Tags = %q, want [a bc]", got.Tags)
+	}
+
+	// The C struct, "alice\0", two char* elements, "a\0" and "bc\0", and
+	// the int32 score; Go string headers (16 bytes) are not C bytes
+	want := int(unsafe.Sizeof(c)) + 6 + 2*8 + 2 + 3 + 4
+	if got := m.events[0].BytesRead; got != want {
+		t.Errorf("BytesRead = %d, want %d", got, want)
+	}
+}
+
+func TestSetMetrics_RecordsCopies(t *testing.T) {
+	Reset()
+	defer Reset()
+	defer SetMetrics(nil)
+
+	if err := Precompile[MetricsUser](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	m := &recordingMetrics{}
+	SetMetrics(m)
+
+	c := cMetricsUser{ID: 1, Name: cString("alice")}
+	if _, err := Copy[MetricsUser](unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	if len(m.events) != 1 {
+		t.Fatalf("recorded %d events, want 1", len(m.events))
+	}
+	event := m.events[0]
+	if event.TypeName != GetMetadata[MetricsUser]().TypeName {
+		t.Errorf("TypeName = %q", event.TypeName)
+	}
+	if want := int(unsafe.Sizeof(MetricsUser{})) + len("alice\x00"); event.BytesRead != want {
+		t.Errorf("BytesRead = %d, want %d", event.BytesRead, want)
+	}
+	if event.Strings != 1 || event.Err != nil || event.Duration <= 0 {
+		t.Errorf("event = %+v, want 1 string, no error and a duration", event)
+	}
+
+	// Failures, updates, projections and bulk elements are recorded too
+	defer SetDefaultLimits(Limits{})
+	SetDefaultLimits(Limits{MaxStringLen: 2})
+	if _, err := Copy[MetricsUser](unsafe.Pointer(&c)); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Copy() error = %v, want ErrLimitExceeded", err)
+	}
+	if !errors.Is(m.events[1].Err, ErrLimitExceeded) {
+		t.Errorf("failed copy event Err = %v, want ErrLimitExceeded", m.events[1].Err)
+	}
+	SetDefaultLimits(Limits{})
+
+	var dst MetricsUser
+	if _, err := Update(&dst, unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	projection, err := NewProjection[MetricsUser]("ID")
+	if err != nil {
+		t.Fatalf("NewProjection() error = %v", err)
+	}
+	if _, err := projection.Copy(unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("Projection.Copy() error = %v", err)
+	}
+	array := []cMetricsUser{c, c}
+	if _, err := CopyArrayParallel[MetricsUser](context.Background(), unsafe.Pointer(&array[0]), 2, 1); err != nil {
+		t.Fatalf("CopyArrayParallel() error = %v", err)
+	}
+
+	if len(m.events) != 6 {
+		t.Errorf("recorded %d events, want 6", len(m.events))
+	}
+
+	// Disabled: nothing is recorded
+	SetMetrics(nil)
+	if _, err := Copy[MetricsUser](unsafe.Pointer(&c)); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if len(m.events) != 6 {
+		t.Errorf("recorded %d events after SetMetrics(nil), want 6", len(m.events))
+	}
+}
+
+func TestCopyMetrics(t *testing.T) {
+	m := NewCopyMetrics()
+	m.RecordCopy(CopyEvent{TypeName: "a.T", BytesRead: 10, Strings: 2, Duration: 100 * time.Nanosecond})
+	m.RecordCopy(CopyEvent{TypeName: "a.T", BytesRead: 5, Duration: 3 * time.Microsecond, Err: ErrNilPointer})
+	m.RecordCopy(CopyEvent{TypeName: "a.T", Duration: time.Second})
+	m.RecordCopy(CopyEvent{TypeName: "b.U", BytesRead: 1, Duration: time.Millisecond})
+
+	snapshot := m.Snapshot()
+	got := snapshot["a.T"]
+	if got.Copies != 3 || got.Errors != 1 || got.BytesRead != 15 || got.Strings != 2 {
+		t.Errorf("a.T counters = %+v", got)
+	}
+	if got.TotalLatency != time.Second+3100*time.Nanosecond {
+		t.Errorf("a.T TotalLatency = %v", got.TotalLatency)
+	}
+
+	counts := make(map[time.Duration]int64)
+	for _, bucket := range got.Latency {
+		counts[bucket.UpperBound] += bucket.Count
+	}
+	if counts[250*time.Nanosecond] != 1 || counts[5*time.Microsecond] != 1 || counts[0] != 1 {
+		t.Errorf("a.T latency buckets = %+v", got.Latency)
+	}
+	if snapshot["b.U"].Copies != 1 {
+		t.Errorf("b.U counters = %+v", snapshot["b.U"])
+	}
+
+	// String is the JSON snapshot, so CopyMetrics can be published with expvar
+	var _ expvar.Var = m
+	var decoded map[string]TypeMetrics
+	if err := json.Unmarshal([]byte(m.String()), &decoded); err != nil {
+		t.Fatalf("String() is not JSON: %v", err)
+	}
+	if decoded["a.T"].Copies != 3 {
+		t.Errorf("decoded a.T = %+v", decoded["a.T"])
+	}
+
+	m.Reset()
+	if len(m.Snapshot()) != 0 {
+		t.Error("Snapshot() not empty after Reset")
+	}
+}
+
+func BenchmarkCopy_Metrics(b *testing.B) {
+	Reset()
+	defer Reset()
+	defer SetMetrics(nil)
+
+	if err := Precompile[MetricsUser](); err != nil {
+		b.Fatalf("Precompile() error = %v", err)
+	}
+	c := cMetricsUser{ID: 1, Name: cString("alice")}
+
+	b.Run("disabled", func(b *testing.B) {
+		SetMetrics(nil)
+		for i := 0; i < b.N; i++ {
+			_, _ = Copy[MetricsUser](unsafe.Pointer(&c))
+		}
+	})
+	b.Run("enabled", func(b *testing.B) {
+		SetMetrics(NewCopyMetrics())
+		for i := 0; i < b.N; i++ {
+			_, _ = Copy[MetricsUser](unsafe.Pointer(&c))
+		}
+	})
+}
@@ -44,6 +44,8 @@
     double: "float64", \
     char*: "string", \
     const char*: "string", \
+    void*: "pointer", \
+    const void*: "pointer", \
     default: "struct" \
 )
 
@@ -102,6 +104,16 @@ typedef struct {
         char*: 1, \
         const char*: 1, \
         void*: 1, \
+        const void*: 1, \
+        default: 0 \
+    )
+
+// _Generic decays arrays, so char[N] would look like a char*. Taking the
+// address keeps the array type: &field is char (*)[N] only for char arrays.
+#define CGOCOPY_IS_CHAR_ARRAY(x) \
+    _Generic(&(x), \
+        char (*)[sizeof(x)]: 1, \
+        const char (*)[sizeof(x)]: 1, \
         default: 0 \
     )
 
@@ -132,20 +144,24 @@ typedef struct {
  * 1. Extracts the field name as a string
  * 2. Detects the field type via _Generic
  * 3. Calculates the offset using offsetof()
- * 4. Determines if it's a pointer or array
- * 5. For arrays, manually populate array_len (see CGOCOPY_ARRAY_FIELD)
+ * 4. Determines if it's a pointer or an inline char array
+ * 5. For other arrays, use CGOCOPY_ARRAY_FIELD to get the element type
  * 
- * Note: For non-array fields, array_len will be 0
+ * Inline char arrays (char tag[8]) are emitted as "char[]" arrays, never
+ * as a char* string. For other non-array fields, array_len will be 0
  */
 #define CGOCOPY_FIELD(structtype, field) \
     { \
         .name = #field, \
-        .type = CGOCOPY_TYPE_NAME(((structtype){0}).field), \
+        .type = CGOCOPY_IS_CHAR_ARRAY(((structtype){0}).field) \
+            ? "char[]" : CGOCOPY_TYPE_NAME(((structtype){0}).field), \
         .offset = offsetof(structtype, field), \
         .size = sizeof(((structtype){0}).field), \
-        .is_pointer = CGOCOPY_IS_POINTER_TYPE(((structtype){0}).field), \
-        .is_array = 0, \
-        .array_len = 0 \
+        .is_pointer = !CGOCOPY_IS_CHAR_ARRAY(((structtype){0}).field) && \
+            CGOCOPY_IS_POINTER_TYPE(((structtype){0}).field), \
+        .is_array = CGOCOPY_IS_CHAR_ARRAY(((structtype){0}).field), \
+        .array_len = CGOCOPY_IS_CHAR_ARRAY(((structtype){0}).field) \
+            ? sizeof(((structtype){0}).field) : 0 \
     }
 
 /*
@@ -165,6 +181,48 @@ typedef struct {
         .array_len = sizeof(((structtype){0}).field) / sizeof(elemtype) \
     }
 
+/*
+ * Special macro for typed and function pointer fields
+ *
+ * _Generic cannot recognise arbitrary pointer types, so fields such as
+ * `Node* next` or `void (*callback)(void*)` must be marked explicitly.
+ * Go fields of type unsafe.Pointer or uintptr receive the raw address.
+ *
+ * Usage: CGOCOPY_POINTER_FIELD(StructType, field_name)
+ * Example: CGOCOPY_POINTER_FIELD(Widget, on_click)
+ */
+#define CGOCOPY_POINTER_FIELD(structtype, field) \
+    { \
+        .name = #field, \
+        .type = "pointer", \
+        .offset = offsetof(structtype, field), \
+        .size = sizeof(((structtype){0}).field), \
+        .is_pointer = 1, \
+        .is_array = 0, \
+        .array_len = 0 \
+    }
+
+/*
+ * Special macro for nested struct fields
+ *
+ * _Generic reports every struct as "struct", which does not say which C
+ * struct the field holds. This macro records the nested type name, so Go
+ * can look up its metadata by name (e.g. CopyToMap, AddCMetadata).
+ *
+ * Usage: CGOCOPY_STRUCT_FIELD(StructType, field_name, nested_type)
+ * Example: CGOCOPY_STRUCT_FIELD(GameObject, position, Point3D)
+ */
+#define CGOCOPY_STRUCT_FIELD(structtype, field, nested_type) \
+    { \
+        .name = #field, \
+        .type = #nested_type, \
+        .offset = offsetof(structtype, field), \
+        .size = sizeof(((structtype){0}).field), \
+        .is_pointer = 0, \
+        .is_array = 0, \
+        .array_len = 0 \
+    }
+
 // ============================================================================
 // Main Macro: CGOCOPY_STRUCT
 // ============================================================================
@@ -0,0 +1,4 @@
+// Links the constructor-based native metadata registry (native/) into every
+// binary that imports this package, so C code registering metadata with
+// CGOCOPY_STRUCT_BEGIN/END and PrecompileAuto share one registry.
+#include "../../native/metadata_registry.c"
@@ -0,0 +1,110 @@
+package cgocopy2
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// applyNullOptions reads the `required` and `default=...` tag options that
+// control how NULL C pointers are copied, and records them on fieldInfo.
+//
+// They apply to string fields (char*), and to pointer fields such as *string,
+// *int32 or *Struct. Without options a NULL char* becomes "" for string
+// fields and nil for pointer fields.
+func applyNullOptions(fieldInfo *FieldInfo, opts tagOptions) error {
+	_, required := opts["required"]
+	defaultText, hasDefault := opts["default"]
+	if !required && !hasDefault {
+		return nil
+	}
+
+	if fieldInfo.Type != FieldTypeString && fieldInfo.Type != FieldTypePointer {
+		return fmt.Errorf("%w: required and default apply only to string and pointer fields", ErrTagFormat)
+	}
+	if required && hasDefault {
+		return fmt.Errorf("%w: required and default are mutually exclusive", ErrTagFormat)
+	}
+
+	fieldInfo.Required = required
+	if hasDefault {
+		value, err := parseDefaultValue(fieldInfo.ReflectType, defaultText)
+		if err != nil {
+			return err
+		}
+		fieldInfo.HasDefault = true
+		fieldInfo.Default = defaultText
+		fieldInfo.defaultValue = value
+	}
+	return nil
+}
+
+// parseDefaultValue converts the text of a default=... option to a value of
+// type t. Pointer types yield a pointer to the parsed element; struct
+// pointers only accept an empty default, meaning a pointer to the zero value.
+func parseDefaultValue(t reflect.Type, text string) (reflect.Value, error) {
+	if t.Kind() == reflect.Ptr {
+		elem, err := parseDefaultValue(t.Elem(), text)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	}
+
+	value := reflect.New(t).Elem()
+	var err error
+	switch t.Kind() {
+	case reflect.String:
+		value.SetString(text)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(text)
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(text, 0, t.Bits())
+		value.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(text, 0, t.Bits())
+		value.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(text, t.Bits())
+		value.SetFloat(f)
+	case reflect.Struct:
+		if text != "" {
+			err = fmt.Errorf("struct defaults must be empty (zero value)")
+		}
+	default:
+		err = ErrUnsupportedType
+	}
+
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("%w: invalid default %q for %v: %v", ErrTagFormat, text, t, err)
+	}
+	return value, nil
+}
+
+// copyNull handles a NULL C pointer for a string or pointer field, applying
+// the field's required and default options.
+func copyNull(goField reflect.Value, field *FieldInfo) error {
+	switch {
+	case field.Required:
+		return ErrRequiredField
+	case field.HasDefault:
+		if field.defaultValue.Kind() == reflect.Ptr {
+			// Give each copy its own pointee
+			ptr := reflect.New(field.defaultValue.Type().Elem())
+			ptr.Elem().Set(field.defaultValue.Elem())
+			goField.Set(ptr)
+		} else {
+			goField.Set(field.defaultValue)
+		}
+	default:
+		goField.Set(reflect.Zero(field.ReflectType))
+	}
+	return nil
+}
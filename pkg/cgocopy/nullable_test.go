@@ -0,0 +1,215 @@
+package cgocopy2
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type NullableParent struct {
+	ID int32
+}
+
+type NullableRecord struct {
+	Nick    *string
+	Title   string          `cgocopy:"title,default=untitled"`
+	Retries *int32          `cgocopy:"retries,default=3"`
+	Parent  *NullableParent `cgocopy:"parent,default"`
+}
+
+type cNullableRecord struct {
+	Nick    *byte
+	Title   *byte
+	Retries *int32
+	Parent  *NullableParent
+}
+
+var nullableRecordInfo = mirrorCInfo[cNullableRecord]("NullableRecord",
+	"nick string", "title string", "retries int32", "parent struct")
+
+func TestCopy_NullableNULLs(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, Precompile[NullableParent]())
+	mustPrecompile(t, PrecompileWithC[NullableRecord](nullableRecordInfo))
+
+	var c cNullableRecord
+	got, err := Copy[NullableRecord](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	if got.Nick != nil {
+		t.Errorf("Nick = %q, want nil", *got.Nick)
+	}
+	if got.Title != "untitled" {
+		t.Errorf("Title = %q, want %q", got.Title, "untitled")
+	}
+	if got.Retries == nil || *got.Retries != 3 {
+		t.Errorf("Retries = %v, want pointer to 3", got.Retries)
+	}
+	if got.Parent == nil || got.Parent.ID != 0 {
+		t.Errorf("Parent = %v, want pointer to zero value", got.Parent)
+	}
+
+	// Defaults must not be shared between copies
+	again, err := Copy[NullableRecord](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if again.Retries == got.Retries {
+		t.Error("default pointer shared between copies")
+	}
+}
+
+func TestCopy_StringPointerReadsCharPointer(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	// A *string field is backed by a char* (not a char**): the C field
+	// holds the string itself
+	type Label struct {
+		Text *string
+	}
+	type cLabel struct {
+		Text *byte
+	}
+	mustPrecompile(t, Precompile[Label]())
+
+	c := cLabel{Text: cString("hello")}
+	got, err := Copy[Label](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if got.Text == nil || *got.Text != "hello" {
+		t.Errorf("Text = %v, want pointer to %q", got.Text, "hello")
+	}
+
+	// Without C metadata there is no C type to check a char** against, so
+	// validation passes; the README and CHANGELOG call this out
+	if GetMetadata[Label]().CInfo != nil {
+		t.Fatal("Label registered with C metadata, want none")
+	}
+	if err := ValidateStruct[Label](); err != nil {
+		t.Errorf("ValidateStruct() error = %v, want nil", err)
+	}
+}
+
+func TestCopy_NullableValues(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, Precompile[NullableParent]())
+	mustPrecompile(t, PrecompileWithC[NullableRecord](nullableRecordInfo))
+
+	retries := int32(7)
+	c := cNullableRecord{
+		Nick:    cString("neo"),
+		Title:   cString("operator"),
+		Retries: &retries,
+		Parent:  &NullableParent{ID: 9},
+	}
+	got, err := Copy[NullableRecord](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	if got.Nick == nil || *got.Nick != "neo" {
+		t.Errorf("Nick = %v, want pointer to %q", got.Nick, "neo")
+	}
+	if got.Title != "operator" {
+		t.Errorf("Title = %q, want %q", got.Title, "operator")
+	}
+	if got.Retries == nil || *got.Retries != 7 {
+		t.Errorf("Retries = %v, want pointer to 7", got.Retries)
+	}
+	if got.Parent == nil || got.Parent.ID != 9 {
+		t.Errorf("Parent = %v, want ID 9", got.Parent)
+	}
+}
+
+func TestCopy_RequiredNULL(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type RequiredRecord struct {
+		Name   string          `cgocopy:"name,required"`
+		Parent *NullableParent `cgocopy:"parent,required"`
+	}
+	type cRequiredRecord struct {
+		Name   *byte
+		Parent *NullableParent
+	}
+
+	mustPrecompile(t, Precompile[NullableParent]())
+	mustPrecompile(t, PrecompileWithC[RequiredRecord](
+		mirrorCInfo[cRequiredRecord]("RequiredRecord", "name string", "parent struct")))
+
+	var c cRequiredRecord
+	c.Parent = &NullableParent{ID: 1}
+	_, err := Copy[RequiredRecord](unsafe.Pointer(&c))
+	if !errors.Is(err, ErrRequiredField) {
+		t.Fatalf("Copy() error = %v, want ErrRequiredField", err)
+	}
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) || copyErr.FieldName != "Name" {
+		t.Errorf("Copy() error = %v, want CopyError for Name", err)
+	}
+
+	c.Name = cString("x")
+	c.Parent = nil
+	_, err = Copy[RequiredRecord](unsafe.Pointer(&c))
+	if !errors.Is(err, ErrRequiredField) {
+		t.Errorf("Copy() error = %v, want ErrRequiredField", err)
+	}
+}
+
+func TestPrecompile_NullOptionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{"option on primitive", func() error {
+			type T struct {
+				X int32 `cgocopy:"x,required"`
+			}
+			return Precompile[T]()
+		}},
+		{"required with default", func() error {
+			type T struct {
+				P *int32 `cgocopy:"p,required,default=1"`
+			}
+			return Precompile[T]()
+		}},
+		{"unparsable default", func() error {
+			type T struct {
+				P *int32 `cgocopy:"p,default=abc"`
+			}
+			return Precompile[T]()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Reset()
+			defer Reset()
+			if err := tt.fn(); err == nil {
+				t.Error("Precompile() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestParseDefaultValue(t *testing.T) {
+	v, err := parseDefaultValue(reflect.TypeOf((*float64)(nil)), "2.5")
+	if err != nil {
+		t.Fatalf("parseDefaultValue() error = %v", err)
+	}
+	if got := v.Elem().Float(); got != 2.5 {
+		t.Errorf("parseDefaultValue() = %v, want 2.5", got)
+	}
+
+	if _, err := parseDefaultValue(reflect.TypeOf((*NullableParent)(nil)), "x"); !errors.Is(err, ErrTagFormat) {
+		t.Errorf("parseDefaultValue(struct, \"x\") error = %v, want ErrTagFormat", err)
+	}
+}
@@ -0,0 +1,235 @@
+package cgocopy2
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// Projection copies only a selected subset of the fields of a registered
+// type T. Fields outside the projection keep their zero values and their C
+// memory is never read, so no strings are walked and nothing is allocated
+// for them.
+//
+// A Projection is immutable and safe for concurrent use. It captures the
+// registered metadata when created, so create it after Precompile.
+type Projection[T any] struct {
+	registry *Registry
+	goType   reflect.Type
+	metadata *StructMetadata
+	fields   []projectedField
+}
+
+// projectedField is one selected field, either copied whole or narrowed to
+// a subset of its nested struct's fields.
+type projectedField struct {
+	field  *FieldInfo
+	nested []projectedField // nil means copy the whole field
+
+	// nestedMeta is the metadata of a narrowed nested struct, whose hooks
+	// run after its projected fields are copied.
+	nestedMeta *StructMetadata
+}
+
+// NewProjection builds a projection of T from field paths. A path is a Go
+// field name ("Email"), or a dotted path into nested struct fields
+// ("Details.Level"). C field names are accepted as well.
+//
+// Example:
+//
+//	var userSummary = must(cgocopy2.NewProjection[User]("ID", "Details.Level"))
+//
+//	user, err := userSummary.Copy(cPtr)
+func NewProjection[T any](paths ...string) (*Projection[T], error) {
+	return NewProjectionWith[T](globalRegistry, paths...)
+}
+
+// NewProjectionWith is like NewProjection but resolves T, and every nested
+// type, in reg.
+func NewProjectionWith[T any](reg *Registry, paths ...string) (*Projection[T], error) {
+	goType := reflect.TypeFor[T]()
+
+	if goType.Kind() != reflect.Struct {
+		return nil, newRegistrationError(goType, "only struct types can be projected", ErrInvalidType)
+	}
+
+	metadata := reg.Get(goType)
+	if metadata == nil {
+		return nil, newRegistrationError(goType, "type not registered", ErrNotRegistered)
+	}
+
+	p := &Projection[T]{registry: reg, goType: goType, metadata: metadata}
+	for _, path := range paths {
+		fields, err := addProjectionPath(reg, p.fields, metadata, strings.Split(path, "."))
+		if err != nil {
+			return nil, newValidationError(metadata.TypeName, path, nil, "", err.Error())
+		}
+		p.fields = fields
+	}
+
+	return p, nil
+}
+
+// addProjectionPath merges one split path into the selected fields.
+func addProjectionPath(registry *Registry, fields []projectedField, metadata *StructMetadata, path []string) ([]projectedField, error) {
+	field := findProjectedFieldInfo(metadata, path[0])
+	if field == nil {
+		return nil, fmt.Errorf("unknown field %q in projection path", path[0])
+	}
+
+	idx := -1
+	for i := range fields {
+		if fields[i].field == field {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		fields = append(fields, projectedField{field: field})
+		idx = len(fields) - 1
+		// A new leaf selects the whole field unless narrowed below
+		if len(path) > 1 {
+			fields[idx].nested = []projectedField{}
+		}
+	}
+
+	if len(path) == 1 {
+		// Selecting the whole field supersedes any narrower selection
+		fields[idx].nested = nil
+		return fields, nil
+	}
+
+	if fields[idx].nested == nil {
+		// Already copying the whole field
+		return fields, nil
+	}
+
+	if field.Type != FieldTypeStruct {
+		return nil, fmt.Errorf("field %q is not a nested struct", path[0])
+	}
+	nestedMeta := registry.Get(field.ReflectType)
+	if nestedMeta == nil {
+		return nil, fmt.Errorf("nested struct type %s not registered", field.ReflectType)
+	}
+
+	nested, err := addProjectionPath(registry, fields[idx].nested, nestedMeta, path[1:])
+	if err != nil {
+		return nil, err
+	}
+	fields[idx].nested = nested
+	fields[idx].nestedMeta = nestedMeta
+	return fields, nil
+}
+
+// findProjectedFieldInfo looks a field up by Go name, then by C name.
+func findProjectedFieldInfo(metadata *StructMetadata, name string) *FieldInfo {
+	for i := range metadata.Fields {
+		if metadata.Fields[i].Name == name {
+			return &metadata.Fields[i]
+		}
+	}
+	for i := range metadata.Fields {
+		if metadata.Fields[i].CName == name {
+			return &metadata.Fields[i]
+		}
+	}
+	return nil
+}
+
+// Copy creates a T from the C struct at cPtr, copying only projected fields.
+// The post-copy hooks of T and of narrowed nested structs run on the
+// partially copied values, as with Copy.
+func (p *Projection[T]) Copy(cPtr unsafe.Pointer) (T, error) {
+	var result T
+	if cPtr == nil {
+		return result, ErrNilPointer
+	}
+
+	state := newCopyState(p.registry, p.metadata.Limits.merge(DefaultLimits()))
+	state.startLogging(p.metadata)
+	dst := reflect.ValueOf(&result).Elem()
+
+	region := traceRegion(context.Background(), "Projection.Copy", p.metadata.TypeName)
+	defer endTraceRegion(region)
+
+	metrics := loadMetrics()
+	if metrics == nil {
+		return result, p.copy(state, dst, cPtr)
+	}
+
+	start := time.Now()
+	err := p.copy(state, dst, cPtr)
+	recordCopy(metrics, p.metadata, state, start, err)
+	return result, err
+}
+
+// copy copies the projected fields into dst, wrapping failures in a
+// CopyError.
+func (p *Projection[T]) copy(state *copyState, dst reflect.Value, cPtr unsafe.Pointer) error {
+	if err := state.copyProjected(dst, cPtr, p.fields); err != nil {
+		path, cause := splitFieldPath(err)
+		return newCopyError(p.goType, path, "failed to copy field", cause)
+	}
+	if err := runCopyHooks(dst, p.metadata); err != nil {
+		return newCopyError(p.goType, "", "post-copy hook failed", err)
+	}
+	return nil
+}
+
+// copyProjected copies the selected fields of a struct.
+func (s *copyState) copyProjected(dst reflect.Value, cPtr unsafe.Pointer, fields []projectedField) error {
+	for i := range fields {
+		pf := &fields[i]
+		goField := goFieldValue(dst, pf.field)
+		cFieldPtr := unsafe.Pointer(uintptr(cPtr) + pf.field.Offset)
+
+		if pf.nested == nil {
+			if err := s.copyLoggedField(goField, cFieldPtr, pf.field); err != nil {
+				return withFieldPath(pf.field.Name, err)
+			}
+			continue
+		}
+
+		if err := s.enter(pf.nestedMeta); err != nil {
+			s.leave()
+			return err
+		}
+		if s.logging {
+			s.path = append(s.path, pf.field.Name)
+		}
+		err := s.copyProjected(goField, cFieldPtr, pf.nested)
+		if s.logging {
+			s.path = s.path[:len(s.path)-1]
+		}
+		s.leave()
+		if err == nil {
+			// Nested hooks run before the enclosing struct's hooks
+			err = runCopyHooks(goField, pf.nestedMeta)
+		}
+		if err != nil {
+			return withFieldPath(pf.field.Name, err)
+		}
+	}
+	return nil
+}
+
+// CopyFields copies only the named fields of T from a C struct pointer.
+// It is a convenience wrapper around NewProjection for one-off calls; build a
+// Projection once when copying the same fields repeatedly.
+func CopyFields[T any](cPtr unsafe.Pointer, paths ...string) (T, error) {
+	return CopyFieldsWith[T](globalRegistry, cPtr, paths...)
+}
+
+// CopyFieldsWith is like CopyFields but resolves T, and every nested type,
+// in reg.
+func CopyFieldsWith[T any](reg *Registry, cPtr unsafe.Pointer, paths ...string) (T, error) {
+	p, err := NewProjectionWith[T](reg, paths...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return p.Copy(cPtr)
+}
@@ -0,0 +1,142 @@
+package cgocopy2
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+type ProjectionDetails struct {
+	FullName string
+	Level    uint32
+}
+
+type ProjectionUser struct {
+	ID      uint32
+	Email   string
+	Details ProjectionDetails
+	Balance float64
+}
+
+// cProjectionUser has C-style char* strings where the Go type has strings.
+type cProjectionUser struct {
+	ID      uint32
+	Email   *byte
+	Details cProjectionDetails
+	Balance float64
+}
+
+type cProjectionDetails struct {
+	FullName *byte
+	Level    uint32
+}
+
+var (
+	projectionDetailsInfo = mirrorCInfo[cProjectionDetails]("ProjectionDetails", "full_name string", "level uint32")
+	projectionUserInfo    = mirrorCInfo[cProjectionUser]("ProjectionUser",
+		"id uint32", "email string", "details struct", "balance float64")
+)
+
+func newCProjectionUser() cProjectionUser {
+	var c cProjectionUser
+	c.ID = 7
+	c.Email = cString("ada@example.com")
+	c.Details.FullName = cString("Ada Lovelace")
+	c.Details.Level = 3
+	c.Balance = 12.5
+	return c
+}
+
+func TestProjection_Copy(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, PrecompileWithC[ProjectionDetails](projectionDetailsInfo))
+	mustPrecompile(t, PrecompileWithC[ProjectionUser](projectionUserInfo))
+
+	p, err := NewProjection[ProjectionUser]("ID", "Details.Level")
+	if err != nil {
+		t.Fatalf("NewProjection() error = %v", err)
+	}
+
+	c := newCProjectionUser()
+	result, err := p.Copy(unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	want := ProjectionUser{ID: 7, Details: ProjectionDetails{Level: 3}}
+	if result != want {
+		t.Errorf("Copy() = %+v, want %+v", result, want)
+	}
+}
+
+func TestProjection_SkipsUnselectedMemory(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, PrecompileWithC[ProjectionDetails](projectionDetailsInfo))
+	mustPrecompile(t, PrecompileWithC[ProjectionUser](projectionUserInfo))
+
+	// Walking any string would trip the limit, so unselected strings must
+	// never be read
+	if err := SetTypeLimits[ProjectionUser](Limits{MaxStringLen: 1}); err != nil {
+		t.Fatalf("SetTypeLimits() error = %v", err)
+	}
+
+	c := newCProjectionUser()
+	result, err := CopyFields[ProjectionUser](unsafe.Pointer(&c), "balance", "Details.level")
+	if err != nil {
+		t.Fatalf("CopyFields() error = %v", err)
+	}
+
+	want := ProjectionUser{Details: ProjectionDetails{Level: 3}, Balance: 12.5}
+	if result != want {
+		t.Errorf("CopyFields() = %+v, want %+v", result, want)
+	}
+}
+
+func TestProjection_WholeFieldSupersedesNested(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, PrecompileWithC[ProjectionDetails](projectionDetailsInfo))
+	mustPrecompile(t, PrecompileWithC[ProjectionUser](projectionUserInfo))
+
+	p, err := NewProjection[ProjectionUser]("Details.Level", "Details")
+	if err != nil {
+		t.Fatalf("NewProjection() error = %v", err)
+	}
+
+	c := newCProjectionUser()
+	result, err := p.Copy(unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if result.Details.FullName != "Ada Lovelace" {
+		t.Errorf("Details.FullName = %q, want whole Details copied", result.Details.FullName)
+	}
+}
+
+func TestNewProjection_Errors(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if _, err := NewProjection[ProjectionUser]("ID"); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("NewProjection() on unregistered type error = %v, want ErrNotRegistered", err)
+	}
+	if _, err := NewProjection[int]("ID"); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("NewProjection[int]() error = %v, want ErrInvalidType", err)
+	}
+	if _, err := NewProjection[any]("ID"); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("NewProjection[any]() error = %v, want ErrInvalidType", err)
+	}
+
+	mustPrecompile(t, PrecompileWithC[ProjectionDetails](projectionDetailsInfo))
+	mustPrecompile(t, PrecompileWithC[ProjectionUser](projectionUserInfo))
+
+	for _, path := range []string{"Missing", "Details.Missing", "ID.Sub"} {
+		_, err := NewProjection[ProjectionUser](path)
+		var valErr *ValidationError
+		if !errors.As(err, &valErr) {
+			t.Errorf("NewProjection(%q) error = %v, want ValidationError", path, err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package cgocopy2
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 	"unsafe"
@@ -9,6 +10,7 @@ import (
 // Precompile analyzes a Go struct type and registers its metadata for efficient copying.
 // This function must be called at initialization time for all types that will be copied.
 // It uses reflection to analyze the struct layout and extract field information.
+// Nested, element and pointee struct types are registered automatically.
 //
 // Example:
 //
@@ -21,34 +23,7 @@ import (
 //	    cgocopy2.Precompile[User]()
 //	}
 func Precompile[T any]() error {
-	var zero T
-	goType := reflect.TypeOf(zero)
-
-	// Dereference pointer types
-	if goType.Kind() == reflect.Ptr {
-		goType = goType.Elem()
-	}
-
-	// Check if already registered
-	if globalRegistry.IsRegistered(goType) {
-		return newRegistrationError(goType, "type already registered", ErrAlreadyRegistered)
-	}
-
-	// Validate that it's a struct
-	if goType.Kind() != reflect.Struct {
-		return newRegistrationError(goType, "only struct types can be precompiled", ErrInvalidType)
-	}
-
-	// Analyze the struct
-	metadata, err := analyzeStruct(goType)
-	if err != nil {
-		return newRegistrationError(goType, "failed to analyze struct", err)
-	}
-
-	// Register the metadata
-	globalRegistry.Register(goType, metadata)
-
-	return nil
+	return globalRegistry.Precompile(reflect.TypeFor[T]())
 }
 
 // PrecompileWithC analyzes a Go struct type and registers it with C struct metadata.
@@ -74,16 +49,34 @@ func Precompile[T any]() error {
 //	    cgocopy2.PrecompileWithC[User](cMetadata)
 //	}
 func PrecompileWithC[T any](cInfo CStructInfo) error {
-	var zero T
-	goType := reflect.TypeOf(zero)
+	return globalRegistry.PrecompileWithC(reflect.TypeFor[T](), cInfo)
+}
 
+// Precompile analyzes goType and registers its metadata in r. It is the
+// registry-scoped form of Precompile[T]; pointer types are dereferenced.
+//
+// Nested struct, array and slice element, pointer and Lazy target types that
+// are not registered yet are analyzed and registered too, dependencies first.
+// If any of them fails, nothing is registered.
+//
+// Example:
+//
+//	reg := cgocopy2.NewRegistry()
+//	if err := reg.Precompile(reflect.TypeFor[User]()); err != nil {
+//	    log.Fatal(err)
+//	}
+func (r *Registry) Precompile(goType reflect.Type) error {
 	// Dereference pointer types
 	if goType.Kind() == reflect.Ptr {
 		goType = goType.Elem()
 	}
 
+	if r.IsSealed() {
+		return newRegistrationError(goType, "registry is sealed", ErrRegistrySealed)
+	}
+
 	// Check if already registered
-	if globalRegistry.IsRegistered(goType) {
+	if r.IsRegistered(goType) {
 		return newRegistrationError(goType, "type already registered", ErrAlreadyRegistered)
 	}
 
@@ -92,49 +85,75 @@ func PrecompileWithC[T any](cInfo CStructInfo) error {
 		return newRegistrationError(goType, "only struct types can be precompiled", ErrInvalidType)
 	}
 
-	// Analyze the struct using C metadata
-	metadata, err := analyzeStructWithC(goType, cInfo)
-	if err != nil {
-		return newRegistrationError(goType, "failed to analyze struct with C metadata", err)
+	// Analyze the struct and any unregistered types it depends on
+	plan := newRegistrationPlan(r)
+	if err := plan.add(goType, nil); err != nil {
+		return newRegistrationError(goType, "failed to analyze struct", err)
+	}
+
+	// Register the metadata, dependencies first
+	return r.registerAll(plan.pending)
+}
+
+// PrecompileWithC analyzes goType with C metadata and registers it in r.
+// It is the registry-scoped form of PrecompileWithC[T].
+//
+// Unregistered nested types are registered too, using the C metadata found
+// by C type name in the catalog (see AddCMetadata). Nested types without a
+// catalog entry are left for the caller to register, before or after goType.
+func (r *Registry) PrecompileWithC(goType reflect.Type, cInfo CStructInfo) error {
+	// Dereference pointer types
+	if goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+
+	if r.IsSealed() {
+		return newRegistrationError(goType, "registry is sealed", ErrRegistrySealed)
 	}
 
-	// Register the metadata
-	globalRegistry.Register(goType, metadata)
+	// Check if already registered
+	if r.IsRegistered(goType) {
+		return newRegistrationError(goType, "type already registered", ErrAlreadyRegistered)
+	}
 
-	return nil
+	// Validate that it's a struct
+	if goType.Kind() != reflect.Struct {
+		return newRegistrationError(goType, "only struct types can be precompiled", ErrInvalidType)
+	}
+
+	// Analyze the struct using C metadata, resolving the C metadata of
+	// unregistered nested types by C type name
+	plan := newRegistrationPlan(r)
+	if err := plan.add(goType, &cInfo); err != nil {
+		return newRegistrationError(goType, "failed to analyze struct with C metadata", err)
+	}
+
+	// Register the metadata, dependencies first
+	return r.registerAll(plan.pending)
 }
 
 // analyzeStruct uses reflection to extract field metadata from a struct type.
 func analyzeStruct(goType reflect.Type) (*StructMetadata, error) {
-	typeName := goType.Name()
-	if typeName == "" {
-		typeName = goType.String()
-	}
+	typeName := qualifiedTypeName(goType)
 
 	metadata := &StructMetadata{
 		TypeName:         typeName,
-		CTypeName:        typeName, // Default to same name, can be overridden
+		CTypeName:        typeName, // No C struct: the qualified Go name, exempt from the C name check
 		GoType:           goType,
 		Size:             goType.Size(),
 		Fields:           make([]FieldInfo, 0, goType.NumField()),
 		HasNestedStructs: false,
 		IsPrimitive:      false,
+		HasHooks:         hasCopyHooks(goType),
 	}
 
-	// Analyze each field
-	for i := 0; i < goType.NumField(); i++ {
-		field := goType.Field(i)
-
-		// Skip unexported fields
-		if !field.IsExported() {
-			continue
-		}
+	// Analyze each field, with embedded structs flattened into the outer struct
+	for _, sf := range collectFields(goType) {
+		field := sf.StructField
 
 		// Parse struct tags
-		cName, skip := parseTag(field.Tag.Get("cgocopy"))
-		if skip {
-			continue
-		}
+		tag := field.Tag.Get("cgocopy")
+		cName, _ := parseTag(tag)
 		if cName == "" {
 			cName = field.Name
 		}
@@ -145,6 +164,12 @@ func analyzeStruct(goType reflect.Type) (*StructMetadata, error) {
 			return nil, newValidationError(typeName, field.Name, field.Type, "", err.Error())
 		}
 
+		// Map entries live in separate C fields, so their layout needs C metadata
+		if fieldType == FieldTypeMap {
+			return nil, newValidationError(typeName, field.Name, field.Type, cName,
+				"map fields require C metadata (use PrecompileWithC)")
+		}
+
 		// Track nested structs
 		if fieldType == FieldTypeStruct {
 			metadata.HasNestedStructs = true
@@ -158,6 +183,8 @@ func analyzeStruct(goType reflect.Type) (*StructMetadata, error) {
 			elemType = field.Type.Elem()
 		} else if fieldType == FieldTypeSlice || fieldType == FieldTypePointer {
 			elemType = field.Type.Elem()
+		} else if fieldType == FieldTypeLazy {
+			elemType = lazyElemType(field.Type)
 		}
 
 		fieldInfo := FieldInfo{
@@ -167,11 +194,15 @@ func analyzeStruct(goType reflect.Type) (*StructMetadata, error) {
 			Offset:      field.Offset,
 			Size:        field.Type.Size(),
 			Skip:        false,
-			Index:       i,
+			Index:       sf.indexPath[0],
+			IndexPath:   sf.embeddedPath(),
 			ReflectType: field.Type,
 			ArrayLen:    arrayLen,
 			ElemType:    elemType,
 		}
+		if err := applyNullOptions(&fieldInfo, parseTagOptions(tag)); err != nil {
+			return nil, newValidationError(typeName, field.Name, field.Type, cName, err.Error())
+		}
 
 		metadata.Fields = append(metadata.Fields, fieldInfo)
 	}
@@ -188,10 +219,7 @@ func analyzeStruct(goType reflect.Type) (*StructMetadata, error) {
 // This function uses C struct field offsets instead of Go struct field offsets to handle
 // cases where C and Go have different struct layouts due to padding/alignment.
 func analyzeStructWithC(goType reflect.Type, cInfo CStructInfo) (*StructMetadata, error) {
-	typeName := goType.Name()
-	if typeName == "" {
-		typeName = goType.String()
-	}
+	typeName := qualifiedTypeName(goType)
 
 	metadata := &StructMetadata{
 		TypeName:         typeName,
@@ -201,6 +229,8 @@ func analyzeStructWithC(goType reflect.Type, cInfo CStructInfo) (*StructMetadata
 		Fields:           make([]FieldInfo, 0, goType.NumField()),
 		HasNestedStructs: false,
 		IsPrimitive:      false,
+		HasHooks:         hasCopyHooks(goType),
+		CInfo:            cloneCStructInfo(cInfo),
 	}
 
 	// Create a map of C field names to C field metadata for name-based lookup
@@ -209,21 +239,19 @@ func analyzeStructWithC(goType reflect.Type, cInfo CStructInfo) (*StructMetadata
 		cFieldMap[cInfo.Fields[i].Name] = &cInfo.Fields[i]
 	}
 
+	// C fields named by the len= and values= options of map fields hold map
+	// entries, so positional matching passes over them
+	fields := collectFields(goType)
+	mapEntryFields := mapEntryCFields(fields)
+
 	// Analyze each Go field and match with C field
 	cFieldIdx := 0 // Track position in C fields for positional matching
-	for i := 0; i < goType.NumField(); i++ {
-		field := goType.Field(i)
-
-		// Skip unexported fields
-		if !field.IsExported() {
-			continue
-		}
+	for _, sf := range fields {
+		field := sf.StructField
 
 		// Parse struct tags
-		cName, skip := parseTag(field.Tag.Get("cgocopy"))
-		if skip {
-			continue
-		}
+		tag := field.Tag.Get("cgocopy")
+		cName, _ := parseTag(tag)
 
 		var cField *CFieldInfo
 		var ok bool
@@ -235,8 +263,24 @@ func analyzeStructWithC(goType reflect.Type, cInfo CStructInfo) (*StructMetadata
 				return nil, newValidationError(typeName, field.Name, field.Type, cName,
 					"C field not found in metadata")
 			}
+		} else if sf.flattened {
+			// Flattened embedded field: match by Go field name
+			idx := findCFieldByName(cInfo.Fields, field.Name)
+			if idx < 0 {
+				return nil, newValidationError(typeName, field.Name, field.Type, field.Name,
+					"C field for embedded struct field not found in metadata")
+			}
+			cField = &cInfo.Fields[idx]
+			cName = cField.Name
+			// Positional matching of later fields continues after the header
+			if idx >= cFieldIdx {
+				cFieldIdx = idx + 1
+			}
 		} else {
 			// No tag: match by position (field index)
+			for cFieldIdx < len(cInfo.Fields) && mapEntryFields[cInfo.Fields[cFieldIdx].Name] {
+				cFieldIdx++
+			}
 			if cFieldIdx >= len(cInfo.Fields) {
 				return nil, newValidationError(typeName, field.Name, field.Type, "",
 					"more Go fields than C fields")
@@ -260,6 +304,7 @@ func analyzeStructWithC(goType reflect.Type, cInfo CStructInfo) (*StructMetadata
 		// Get array length and element type for compound types
 		arrayLen := 0
 		var elemType reflect.Type
+		var mapLayout *MapLayout
 		if fieldType == FieldTypeArray {
 			arrayLen = field.Type.Len()
 			elemType = field.Type.Elem()
@@ -270,6 +315,28 @@ func analyzeStructWithC(goType reflect.Type, cInfo CStructInfo) (*StructMetadata
 			}
 		} else if fieldType == FieldTypeSlice || fieldType == FieldTypePointer {
 			elemType = field.Type.Elem()
+		} else if fieldType == FieldTypeLazy {
+			elemType = lazyElemType(field.Type)
+			if !isCPointerField(cField) {
+				return nil, newValidationError(typeName, field.Name, field.Type, cName,
+					"lazy field requires a C pointer field")
+			}
+		} else if fieldType == FieldTypeMap {
+			elemType = field.Type.Elem()
+			mapLayout, err = analyzeMapField(field.Type, cField, parseTagOptions(tag), cFieldMap)
+			if err != nil {
+				return nil, newValidationError(typeName, field.Name, field.Type, cName, err.Error())
+			}
+		} else if fieldType == FieldTypeOpaque {
+			// Opaque fields receive the raw address, so the C side must be a pointer
+			if !isCPointerField(cField) {
+				return nil, newValidationError(typeName, field.Name, field.Type, cName,
+					"opaque field requires a C pointer field")
+			}
+			if cField.Size != field.Type.Size() {
+				return nil, newValidationError(typeName, field.Name, field.Type, cName,
+					"C pointer size does not match Go field size")
+			}
 		}
 
 		// Use C field offset instead of Go field offset!
@@ -277,14 +344,23 @@ func analyzeStructWithC(goType reflect.Type, cInfo CStructInfo) (*StructMetadata
 			Name:        field.Name,
 			CName:       cName,
 			Type:        fieldType,
+			CType:       cField.Type,
 			Offset:      cField.Offset, // <<< Key difference: using C offset
 			Size:        cField.Size,   // <<< Using C size
 			Skip:        false,
-			Index:       i,
+			Index:       sf.indexPath[0],
+			IndexPath:   sf.embeddedPath(),
 			ReflectType: field.Type,
 			ArrayLen:    arrayLen,
 			ElemType:    elemType,
 		}
+		if mapLayout != nil {
+			fieldInfo.KeyType = field.Type.Key()
+			fieldInfo.Map = mapLayout
+		}
+		if err := applyNullOptions(&fieldInfo, parseTagOptions(tag)); err != nil {
+			return nil, newValidationError(typeName, field.Name, field.Type, cName, err.Error())
+		}
 
 		metadata.Fields = append(metadata.Fields, fieldInfo)
 	}
@@ -297,6 +373,251 @@ func analyzeStructWithC(goType reflect.Type, cInfo CStructInfo) (*StructMetadata
 	return metadata, nil
 }
 
+// qualifiedTypeName returns the package-qualified name of a Go type
+// ("example.com/app.User", "example.com/app.Box[int32]" for generic
+// instantiations), or its string form for unnamed types. It identifies
+// types in TypeName, the default C name and the schema.
+func qualifiedTypeName(t reflect.Type) string {
+	if t.Name() == "" || t.PkgPath() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// cloneCStructInfo returns a copy of cInfo that shares no memory with the
+// caller's value.
+func cloneCStructInfo(cInfo CStructInfo) *CStructInfo {
+	clone := cInfo
+	clone.Fields = append([]CFieldInfo(nil), cInfo.Fields...)
+	return &clone
+}
+
+// structField is a Go struct field reached from the top-level struct,
+// possibly through flattened embedded structs.
+type structField struct {
+	reflect.StructField
+
+	// indexPath is the reflect index sequence from the top-level struct.
+	indexPath []int
+
+	// flattened is true for fields promoted from a flattened embedded struct.
+	flattened bool
+}
+
+// embeddedPath returns the index path for flattened fields and nil for
+// direct fields, matching FieldInfo.IndexPath.
+func (sf structField) embeddedPath() []int {
+	if len(sf.indexPath) < 2 {
+		return nil
+	}
+	return sf.indexPath
+}
+
+// collectFields lists the copyable fields of a struct in declaration order.
+// Unexported and `cgocopy:"-"` fields are dropped. Embedded structs are
+// flattened (their fields take the place of the embedded field) unless the
+// field carries an explicit C name; named struct fields can opt in with
+// the `flatten` tag option. Offsets are relative to the top-level struct.
+func collectFields(goType reflect.Type) []structField {
+	var fields []structField
+	collectFieldsInto(&fields, goType, nil, 0, false)
+	return fields
+}
+
+func collectFieldsInto(fields *[]structField, goType reflect.Type, parent []int, baseOffset uintptr, flattened bool) {
+	for i := 0; i < goType.NumField(); i++ {
+		field := goType.Field(i)
+
+		tag := field.Tag.Get("cgocopy")
+		cName, skip := parseTag(tag)
+		if skip {
+			continue
+		}
+
+		indexPath := append(append([]int(nil), parent...), i)
+		field.Offset += baseOffset
+
+		if shouldFlatten(field, cName, parseTagOptions(tag)) {
+			collectFieldsInto(fields, field.Type, indexPath, field.Offset, true)
+			continue
+		}
+
+		// Skip unexported fields
+		if !field.IsExported() {
+			continue
+		}
+
+		*fields = append(*fields, structField{
+			StructField: field,
+			indexPath:   indexPath,
+			flattened:   flattened,
+		})
+	}
+}
+
+// shouldFlatten reports whether a struct field is flattened into its parent.
+func shouldFlatten(field reflect.StructField, cName string, opts tagOptions) bool {
+	if field.Type.Kind() != reflect.Struct {
+		return false
+	}
+	if _, ok := opts["flatten"]; ok {
+		return true
+	}
+	return field.Anonymous && cName == ""
+}
+
+// findCFieldByName returns the index of the C field called name, preferring
+// an exact match and falling back to a unique case-insensitive match.
+// Returns -1 if no field matches.
+func findCFieldByName(cFields []CFieldInfo, name string) int {
+	for i := range cFields {
+		if cFields[i].Name == name {
+			return i
+		}
+	}
+
+	match := -1
+	for i := range cFields {
+		if strings.EqualFold(cFields[i].Name, name) {
+			if match >= 0 {
+				return -1
+			}
+			match = i
+		}
+	}
+	return match
+}
+
+// mapEntryCFields returns the names of the C fields that the len= and
+// values= options of map fields refer to.
+func mapEntryCFields(fields []structField) map[string]bool {
+	names := make(map[string]bool)
+	for _, sf := range fields {
+		if sf.Type.Kind() != reflect.Map {
+			continue
+		}
+		opts := parseTagOptions(sf.Tag.Get("cgocopy"))
+		for _, key := range []string{"len", "values"} {
+			if name := opts[key]; name != "" {
+				names[name] = true
+			}
+		}
+	}
+	return names
+}
+
+// analyzeMapField resolves the C layout backing a Go map field.
+// cField is the keys pointer (paired arrays) or the pairs pointer; the
+// remaining C fields are named by the values= and len= tag options.
+func analyzeMapField(goType reflect.Type, cField *CFieldInfo, opts tagOptions,
+	cFieldMap map[string]*CFieldInfo) (*MapLayout, error) {
+	keyRepr, ok := cElemRepr(goType.Key())
+	if !ok {
+		return nil, fmt.Errorf("%w: map key type %v", ErrUnsupportedType, goType.Key())
+	}
+	valueRepr, ok := cElemRepr(goType.Elem())
+	if !ok {
+		return nil, fmt.Errorf("%w: map value type %v", ErrUnsupportedType, goType.Elem())
+	}
+
+	ptrSize := unsafe.Sizeof(uintptr(0))
+	if cField.IsArray || cField.Size != ptrSize {
+		return nil, fmt.Errorf("C field %q must be a pointer to the map entries", cField.Name)
+	}
+
+	lenName, ok := opts["len"]
+	if !ok || lenName == "" {
+		return nil, fmt.Errorf("%w: map field requires a len=<c_field> option", ErrTagFormat)
+	}
+	lenField, ok := cFieldMap[lenName]
+	if !ok {
+		return nil, fmt.Errorf("length field %q not found in C metadata", lenName)
+	}
+	switch lenField.Size {
+	case 1, 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("length field %q has unsupported size %d", lenName, lenField.Size)
+	}
+
+	layout := &MapLayout{
+		KeysOffset: cField.Offset,
+		LenOffset:  lenField.Offset,
+		LenSize:    lenField.Size,
+	}
+
+	switch dup := opts["dup"]; dup {
+	case "", "error":
+	case "last":
+		layout.LastWins = true
+	default:
+		return nil, fmt.Errorf("%w: unknown dup=%s (want error or last)", ErrTagFormat, dup)
+	}
+
+	if valuesName, ok := opts["values"]; ok {
+		// Paired arrays: K* keys; V* values; size_t n;
+		valuesField, found := cFieldMap[valuesName]
+		if !found {
+			return nil, fmt.Errorf("values field %q not found in C metadata", valuesName)
+		}
+		if valuesField.IsArray || valuesField.Size != ptrSize {
+			return nil, fmt.Errorf("C field %q must be a pointer to the map values", valuesName)
+		}
+		layout.Paired = true
+		layout.ValuesOffset = valuesField.Offset
+		layout.KeyStride = keyRepr.Size()
+		layout.ValueStride = valueRepr.Size()
+		return layout, nil
+	}
+
+	// Array of pair structs: struct { K key; V value; }* pairs; size_t n;
+	// C lays the pair out with natural alignment, which matches Go's layout
+	// of the same field types.
+	pair := reflect.StructOf([]reflect.StructField{
+		{Name: "Key", Type: keyRepr},
+		{Name: "Value", Type: valueRepr},
+	})
+	layout.KeyStride = pair.Size()
+	layout.ValueStride = pair.Size()
+	layout.ValueOffset = pair.Field(1).Offset
+	return layout, nil
+}
+
+// cElemRepr returns a Go type with the same size and alignment as the C
+// representation of a map key or value: primitives map to themselves and
+// strings to a char* pointer.
+func cElemRepr(t reflect.Type) (reflect.Type, bool) {
+	switch {
+	case isPrimitiveKind(t.Kind()):
+		return t, true
+	case t.Kind() == reflect.String:
+		return reflect.TypeOf(unsafe.Pointer(nil)), true
+	default:
+		return nil, false
+	}
+}
+
+// tagOptions holds the comma-separated options that follow the C field name
+// in a cgocopy tag. Flag options map to an empty string.
+type tagOptions map[string]string
+
+// parseTagOptions parses the options of a cgocopy struct tag.
+//
+// Supported format:
+//   - `cgocopy:"name,flag,key=value"` - options after the first comma
+func parseTagOptions(tag string) tagOptions {
+	parts := strings.Split(tag, ",")
+	opts := make(tagOptions, len(parts)-1)
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		opts[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return opts
+}
+
 // parseTag parses a cgocopy struct tag.
 // Returns (cFieldName, skip).
 //
@@ -304,7 +625,9 @@ func analyzeStructWithC(goType reflect.Type, cInfo CStructInfo) (*StructMetadata
 //   - `cgocopy:"field_name"` - map to C field "field_name"
 //   - `cgocopy:"-"` - skip this field
 //   - `cgocopy:""` or no tag - use Go field name
+//   - `cgocopy:"field_name,options..."` - options are read by parseTagOptions
 func parseTag(tag string) (string, bool) {
+	tag, _, _ = strings.Cut(tag, ",")
 	tag = strings.TrimSpace(tag)
 
 	// Empty tag means use the field name as-is
@@ -323,6 +646,10 @@ func parseTag(tag string) (string, bool) {
 
 // categorizeFieldType determines the FieldType category for a reflect.Type.
 func categorizeFieldType(t reflect.Type) (FieldType, error) {
+	if isLazyType(t) {
+		return FieldTypeLazy, nil
+	}
+
 	switch t.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
@@ -344,7 +671,13 @@ func categorizeFieldType(t reflect.Type) (FieldType, error) {
 	case reflect.Ptr:
 		return FieldTypePointer, nil
 
-	case reflect.Interface, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+	case reflect.UnsafePointer, reflect.Uintptr:
+		return FieldTypeOpaque, nil
+
+	case reflect.Map:
+		return FieldTypeMap, nil
+
+	case reflect.Interface, reflect.Chan, reflect.Func:
 		return FieldTypeInvalid, ErrUnsupportedType
 
 	default:
@@ -352,6 +685,27 @@ func categorizeFieldType(t reflect.Type) (FieldType, error) {
 	}
 }
 
+// isCPointerField reports whether C metadata describes a pointer-valued field.
+// The macros flag void* and char* via IsPointer; typed and function pointers
+// declared with CGOCOPY_POINTER_FIELD carry the "pointer" type name.
+func isCPointerField(cField *CFieldInfo) bool {
+	if cField.IsArray {
+		return false
+	}
+	return cField.IsPointer || cField.Type == "pointer" || strings.HasSuffix(cField.Type, "*")
+}
+
+// isCStringType reports whether a C type name describes a char*: the
+// "string" name the macros emit, or a spelled-out char pointer.
+func isCStringType(cType string) bool {
+	name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cType), "const "))
+	switch strings.ReplaceAll(name, " ", "") {
+	case "string", "char*":
+		return true
+	}
+	return false
+}
+
 // IsRegistered returns true if the type T has been precompiled.
 func IsRegistered[T any]() bool {
 	var zero T
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"unsafe"
 )
 
 // Test types for Precompile tests
@@ -81,8 +82,9 @@ func TestPrecompile_SimpleStruct(t *testing.T) {
 		t.Fatal("GetMetadata() = nil, want metadata")
 	}
 
-	if metadata.TypeName != "SimpleStruct" {
-		t.Errorf("TypeName = %q, want %q", metadata.TypeName, "SimpleStruct")
+	wantName := "github.com/shaban/cgocopy/pkg/cgocopy.SimpleStruct"
+	if metadata.TypeName != wantName {
+		t.Errorf("TypeName = %q, want %q", metadata.TypeName, wantName)
 	}
 
 	if len(metadata.Fields) != 2 {
@@ -317,6 +319,71 @@ func TestPrecompile_UnsupportedType(t *testing.T) {
 	}
 }
 
+func TestPrecompileWithC_OpaqueFields(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Widget struct {
+		UserData unsafe.Pointer `cgocopy:"user_data"`
+		OnClick  uintptr        `cgocopy:"on_click"`
+	}
+
+	ptrSize := unsafe.Sizeof(uintptr(0))
+	err := PrecompileWithC[Widget](CStructInfo{
+		Name: "Widget",
+		Size: 2 * ptrSize,
+		Fields: []CFieldInfo{
+			{Name: "user_data", Type: "pointer", Offset: 0, Size: ptrSize, IsPointer: true},
+			{Name: "on_click", Type: "pointer", Offset: ptrSize, Size: ptrSize, IsPointer: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+
+	metadata := GetMetadata[Widget]()
+	for _, field := range metadata.Fields {
+		if field.Type != FieldTypeOpaque {
+			t.Errorf("field %q: Type = %v, want %v", field.Name, field.Type, FieldTypeOpaque)
+		}
+	}
+}
+
+func TestPrecompileWithC_OpaqueFieldRequiresCPointer(t *testing.T) {
+	tests := []struct {
+		name   string
+		cField CFieldInfo
+	}{
+		{"not a pointer", CFieldInfo{Name: "handle", Type: "int64", Size: 8}},
+		{"wrong size", CFieldInfo{Name: "handle", Type: "pointer", Size: 2, IsPointer: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Reset()
+			defer Reset()
+
+			type Handle struct {
+				Handle uintptr `cgocopy:"handle"`
+			}
+
+			err := PrecompileWithC[Handle](CStructInfo{
+				Name:   "Handle",
+				Size:   8,
+				Fields: []CFieldInfo{tt.cField},
+			})
+			if err == nil {
+				t.Fatal("PrecompileWithC() error = nil, want validation error")
+			}
+
+			var valErr *ValidationError
+			if !errors.As(err, &valErr) {
+				t.Errorf("error should wrap ValidationError, got %v", err)
+			}
+		})
+	}
+}
+
 func TestPrecompile_UnexportedFields(t *testing.T) {
 	Reset()
 	defer Reset()
@@ -412,6 +479,8 @@ func TestParseTag(t *testing.T) {
 		{"skip marker", "-", "", true},
 		{"field name", "c_field_name", "c_field_name", false},
 		{"with spaces", "  field  ", "field", false},
+		{"with options", "keys,values=vals,len=n", "keys", false},
+		{"options only", ",len=n", "", false},
 	}
 
 	for _, tt := range tests {
@@ -427,6 +496,88 @@ func TestParseTag(t *testing.T) {
 	}
 }
 
+func TestParseTagOptions(t *testing.T) {
+	opts := parseTagOptions("keys, values=vals ,len=n,dup=last,flag")
+
+	want := tagOptions{"values": "vals", "len": "n", "dup": "last", "flag": ""}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("parseTagOptions() = %v, want %v", opts, want)
+	}
+
+	if opts := parseTagOptions("name"); len(opts) != 0 {
+		t.Errorf("parseTagOptions(%q) = %v, want empty", "name", opts)
+	}
+}
+
+func TestPrecompile_MapRequiresCMetadata(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Config struct {
+		Options map[string]string
+	}
+
+	err := Precompile[Config]()
+	if err == nil {
+		t.Fatal("Precompile() error = nil, want error for map field without C metadata")
+	}
+	if !strings.Contains(err.Error(), "C metadata") {
+		t.Errorf("error should mention C metadata, got: %v", err)
+	}
+}
+
+func TestPrecompileWithC_MapTagErrors(t *testing.T) {
+	ptrSize := unsafe.Sizeof(uintptr(0))
+	cInfo := CStructInfo{
+		Name: "Config",
+		Size: 3 * ptrSize,
+		Fields: []CFieldInfo{
+			{Name: "keys", Type: "pointer", Offset: 0, Size: ptrSize, IsPointer: true},
+			{Name: "values", Type: "pointer", Offset: ptrSize, Size: ptrSize, IsPointer: true},
+			{Name: "n", Type: "uint64", Offset: 2 * ptrSize, Size: 8},
+		},
+	}
+
+	t.Run("missing len", func(t *testing.T) {
+		Reset()
+		defer Reset()
+
+		type Config struct {
+			Options map[string]string `cgocopy:"keys,values=values"`
+		}
+		err := PrecompileWithC[Config](cInfo)
+		if err == nil || !strings.Contains(err.Error(), "len=") {
+			t.Errorf("PrecompileWithC() error = %v, want missing len= error", err)
+		}
+	})
+
+	t.Run("unknown values field", func(t *testing.T) {
+		Reset()
+		defer Reset()
+
+		type Config struct {
+			Options map[string]string `cgocopy:"keys,values=vals,len=n"`
+		}
+		err := PrecompileWithC[Config](cInfo)
+		if err == nil || !strings.Contains(err.Error(), "vals") {
+			t.Errorf("PrecompileWithC() error = %v, want unknown values field error", err)
+		}
+	})
+
+	t.Run("unsupported value type", func(t *testing.T) {
+		Reset()
+		defer Reset()
+
+		type Config struct {
+			Options map[string][]int `cgocopy:"keys,values=values,len=n"`
+		}
+		err := PrecompileWithC[Config](cInfo)
+		if err == nil || !strings.Contains(err.Error(), "unsupported") {
+			t.Errorf("PrecompileWithC() error = %v, want unsupported value type error", err)
+		}
+	})
+}
+
 func TestCategorizeFieldType(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -444,8 +595,10 @@ func TestCategorizeFieldType(t *testing.T) {
 		{"array", reflect.TypeOf([5]int{}), FieldTypeArray, false},
 		{"slice", reflect.TypeOf([]int{}), FieldTypeSlice, false},
 		{"pointer", reflect.TypeOf((*int)(nil)), FieldTypePointer, false},
+		{"unsafe.Pointer", reflect.TypeOf(unsafe.Pointer(nil)), FieldTypeOpaque, false},
+		{"uintptr", reflect.TypeOf(uintptr(0)), FieldTypeOpaque, false},
 		{"func", reflect.TypeOf(func() {}), FieldTypeInvalid, true},
-		{"map", reflect.TypeOf(map[string]int{}), FieldTypeInvalid, true},
+		{"map", reflect.TypeOf(map[string]int{}), FieldTypeMap, false},
 		{"chan", reflect.TypeOf(make(chan int)), FieldTypeInvalid, true},
 	}
 
@@ -508,3 +661,636 @@ func TestIsPrimitiveKind(t *testing.T) {
 		}
 	}
 }
+
+func TestRegistry_IndependentMappings(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Pair struct {
+		A int32 `cgocopy:"a"`
+		B int32 `cgocopy:"b"`
+	}
+
+	// Two components map the same Go type onto differently ordered C structs
+	straight := NewRegistry()
+	swapped := NewRegistry()
+	if err := straight.PrecompileWithC(reflect.TypeFor[Pair](), CStructInfo{
+		Name: "pair", Size: 8,
+		Fields: []CFieldInfo{
+			{Name: "a", Type: "int32", Offset: 0, Size: 4},
+			{Name: "b", Type: "int32", Offset: 4, Size: 4},
+		},
+	}); err != nil {
+		t.Fatalf("straight.PrecompileWithC() error = %v", err)
+	}
+	if err := swapped.PrecompileWithC(reflect.TypeFor[Pair](), CStructInfo{
+		Name: "pair", Size: 8,
+		Fields: []CFieldInfo{
+			{Name: "b", Type: "int32", Offset: 0, Size: 4},
+			{Name: "a", Type: "int32", Offset: 4, Size: 4},
+		},
+	}); err != nil {
+		t.Fatalf("swapped.PrecompileWithC() error = %v", err)
+	}
+
+	c := [2]int32{1, 2}
+	got1, err := CopyWith[Pair](straight, unsafe.Pointer(&c))
+	if err != nil || got1 != (Pair{A: 1, B: 2}) {
+		t.Errorf("CopyWith(straight) = %+v, %v, want {1 2}", got1, err)
+	}
+	got2, err := CopyWith[Pair](swapped, unsafe.Pointer(&c))
+	if err != nil || got2 != (Pair{A: 2, B: 1}) {
+		t.Errorf("CopyWith(swapped) = %+v, %v, want {2 1}", got2, err)
+	}
+
+	if IsRegistered[Pair]() {
+		t.Error("Pair registered globally by a scoped registry")
+	}
+	if _, err := Copy[Pair](unsafe.Pointer(&c)); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Copy() error = %v, want ErrNotRegistered", err)
+	}
+}
+
+func TestRegistry_NestedLookupsStayInRegistry(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	reg := NewRegistry()
+	if err := reg.Precompile(reflect.TypeFor[SimpleStruct]()); err != nil {
+		t.Fatalf("reg.Precompile(SimpleStruct) error = %v", err)
+	}
+	if err := reg.Precompile(reflect.TypeFor[*NestedStruct]()); err != nil {
+		t.Fatalf("reg.Precompile(*NestedStruct) error = %v", err)
+	}
+
+	// Registered globally without its nested type: must not be consulted
+	registerWithoutDependencies[NestedStruct](t)
+
+	if err := reg.Validate(reflect.TypeFor[NestedStruct]()); err != nil {
+		t.Errorf("reg.Validate() error = %v", err)
+	}
+	if err := reg.ValidateAll(); err != nil {
+		t.Errorf("reg.ValidateAll() = %v, want no errors", err)
+	}
+	if err := ValidateStruct[NestedStruct](); err == nil {
+		t.Error("ValidateStruct() error = nil, want unregistered nested type in global registry")
+	}
+
+	src := NestedStruct{ID: 3, Profile: SimpleStruct{ID: 4}}
+	got, err := CopyWith[NestedStruct](reg, unsafe.Pointer(&src))
+	if err != nil {
+		t.Fatalf("CopyWith() error = %v", err)
+	}
+	if got.Profile.ID != 4 {
+		t.Errorf("Profile.ID = %d, want 4", got.Profile.ID)
+	}
+	if _, err := Copy[NestedStruct](unsafe.Pointer(&src)); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Copy() error = %v, want ErrNotRegistered from global nested lookup", err)
+	}
+
+	types := reg.RegisteredTypes()
+	if len(types) != 2 {
+		t.Errorf("reg.RegisteredTypes() = %v, want 2 types", types)
+	}
+}
+
+func TestRegistry_LazyResolvesInRegistry(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	var c cLazyDocument
+	reg := NewRegistry()
+	if err := reg.Precompile(reflect.TypeFor[LazyBody]()); err != nil {
+		t.Fatalf("reg.Precompile(LazyBody) error = %v", err)
+	}
+	if err := reg.PrecompileWithC(reflect.TypeFor[LazyDocument](), CStructInfo{
+		Name: "LazyDocument",
+		Size: unsafe.Sizeof(c),
+		Fields: []CFieldInfo{
+			{Name: "id", Type: "int32", Offset: unsafe.Offsetof(c.ID), Size: unsafe.Sizeof(c.ID)},
+			{Name: "body", Type: "struct", Offset: unsafe.Offsetof(c.Body), Size: unsafe.Sizeof(c.Body), IsPointer: true},
+		},
+	}); err != nil {
+		t.Fatalf("reg.PrecompileWithC(LazyDocument) error = %v", err)
+	}
+
+	c.Body = &LazyBody{Size: 11}
+	doc, err := CopyWith[LazyDocument](reg, unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("CopyWith() error = %v", err)
+	}
+	body, err := doc.Body.Get()
+	if err != nil || body.Size != 11 {
+		t.Errorf("Body.Get() = %v, %v, want Size 11", body, err)
+	}
+}
+
+func TestRegistry_LimitsInRegistry(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	reg := NewRegistry()
+	goType := reflect.TypeFor[LimitedRecord]()
+	if err := reg.PrecompileWithC(goType, limitedRecordInfo()); err != nil {
+		t.Fatalf("reg.PrecompileWithC() error = %v", err)
+	}
+	if err := reg.SetTypeLimits(goType, Limits{MaxStringLen: 4}); err != nil {
+		t.Fatalf("reg.SetTypeLimits() error = %v", err)
+	}
+	if err := SetTypeLimits[LimitedRecord](Limits{MaxStringLen: 4}); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("SetTypeLimits() error = %v, want ErrNotRegistered in the global registry", err)
+	}
+
+	c := newCLimitedRecord("abcdefgh", nil)
+	if _, err := CopyWith[LimitedRecord](reg, unsafe.Pointer(&c)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("CopyWith() error = %v, want ErrLimitExceeded from the type limits", err)
+	}
+
+	// Per-call limits take precedence over the registry's type limits
+	got, err := CopyWithLimitsWith[LimitedRecord](reg, unsafe.Pointer(&c), Limits{MaxStringLen: 16})
+	if err != nil || got.Name != "abcdefgh" {
+		t.Errorf("CopyWithLimitsWith() = %+v, %v, want Name abcdefgh", got, err)
+	}
+	if _, err := CopyWithLimits[LimitedRecord](unsafe.Pointer(&c), Limits{}); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("CopyWithLimits() error = %v, want ErrNotRegistered", err)
+	}
+
+	reg.Seal()
+	if err := reg.SetTypeLimits(goType, Limits{}); !errors.Is(err, ErrRegistrySealed) {
+		t.Errorf("reg.SetTypeLimits() after Seal error = %v, want ErrRegistrySealed", err)
+	}
+}
+
+func TestRegistry_IteratorsInRegistry(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	reg := NewRegistry()
+	if err := reg.Precompile(reflect.TypeFor[LazyBody]()); err != nil {
+		t.Fatalf("reg.Precompile() error = %v", err)
+	}
+
+	c := []LazyBody{{Size: 1}, {Size: 2}, {Size: 3}}
+
+	var sizes []int32
+	seq, errf := EachReuseWith[LazyBody](reg, unsafe.Pointer(&c[0]), len(c))
+	for _, body := range seq {
+		sizes = append(sizes, body.Size)
+	}
+	if err := errf(); err != nil || len(sizes) != 3 || sizes[2] != 3 {
+		t.Errorf("EachReuseWith() = %v, %v, want [1 2 3]", sizes, err)
+	}
+
+	sizes = nil
+	bufSeq, errf := EachBufferWith[LazyBody](reg, unsafe.Pointer(&c[0]), unsafe.Sizeof(c[0])*2)
+	for _, body := range bufSeq {
+		sizes = append(sizes, body.Size)
+	}
+	if err := errf(); err != nil || len(sizes) != 2 || sizes[1] != 2 {
+		t.Errorf("EachBufferWith() = %v, %v, want [1 2]", sizes, err)
+	}
+
+	// The global registry does not know the type
+	_, errf = EachBuffer[LazyBody](unsafe.Pointer(&c[0]), unsafe.Sizeof(c[0]))
+	if err := errf(); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("EachBuffer() error = %v, want ErrNotRegistered", err)
+	}
+	reuse, errf := EachReuse[LazyBody](unsafe.Pointer(&c[0]), len(c))
+	for range reuse {
+		t.Error("EachReuse() yielded an unregistered type")
+	}
+	if err := errf(); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("EachReuse() error = %v, want ErrNotRegistered", err)
+	}
+}
+
+func TestRegistry_CopyFieldsInRegistry(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	reg := NewRegistry()
+	if err := reg.Precompile(reflect.TypeFor[LazyBody]()); err != nil {
+		t.Fatalf("reg.Precompile() error = %v", err)
+	}
+
+	c := LazyBody{Size: 5, Words: 9}
+	got, err := CopyFieldsWith[LazyBody](reg, unsafe.Pointer(&c), "Words")
+	if err != nil || got != (LazyBody{Words: 9}) {
+		t.Errorf("CopyFieldsWith() = %+v, %v, want {Words: 9}", got, err)
+	}
+	if _, err := CopyFields[LazyBody](unsafe.Pointer(&c), "Words"); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("CopyFields() error = %v, want ErrNotRegistered", err)
+	}
+}
+
+func TestRegistry_NewLazyInRegistry(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	reg := NewRegistry()
+	if err := reg.Precompile(reflect.TypeFor[LazyBody]()); err != nil {
+		t.Fatalf("reg.Precompile() error = %v", err)
+	}
+
+	c := LazyBody{Size: 7}
+	body, err := NewLazyWith[LazyBody](reg, unsafe.Pointer(&c)).Get()
+	if err != nil || body.Size != 7 {
+		t.Errorf("NewLazyWith().Get() = %v, %v, want Size 7", body, err)
+	}
+	if _, err := NewLazy[LazyBody](unsafe.Pointer(&c)).Get(); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("NewLazy().Get() error = %v, want ErrNotRegistered", err)
+	}
+}
+
+type AutoLeaf struct {
+	Value int32
+}
+
+type AutoMiddle struct {
+	Leaf  AutoLeaf
+	Items [2]AutoLeaf
+}
+
+type AutoRoot struct {
+	Middle AutoMiddle
+	Ptr    *AutoLeaf
+	Self   *AutoRoot
+}
+
+func TestPrecompile_RegistersDependencies(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[AutoRoot](); err != nil {
+		t.Fatalf("Precompile[AutoRoot]() error = %v", err)
+	}
+
+	for _, goType := range []reflect.Type{
+		reflect.TypeFor[AutoRoot](), reflect.TypeFor[AutoMiddle](), reflect.TypeFor[AutoLeaf](),
+	} {
+		if !globalRegistry.IsRegistered(goType) {
+			t.Errorf("%v not registered", goType)
+		}
+	}
+	if err := ValidateStruct[AutoRoot](); err != nil {
+		t.Errorf("ValidateStruct() error = %v", err)
+	}
+
+	// Dependencies were registered by the first call
+	if err := Precompile[AutoLeaf](); !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("Precompile[AutoLeaf]() error = %v, want ErrAlreadyRegistered", err)
+	}
+}
+
+func TestPrecompile_DependencyOrder(t *testing.T) {
+	plan := newRegistrationPlan(NewRegistry())
+	if err := plan.add(reflect.TypeFor[AutoRoot](), nil); err != nil {
+		t.Fatalf("plan.add() error = %v", err)
+	}
+
+	var got []string
+	for _, metadata := range plan.pending {
+		got = append(got, metadata.GoType.Name())
+	}
+	want := []string{"AutoLeaf", "AutoMiddle", "AutoRoot"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("registration order = %v, want %v", got, want)
+	}
+}
+
+func TestPrecompile_DependencyFailureRegistersNothing(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type BadLeaf struct {
+		Events chan int
+	}
+	type Holder struct {
+		ID   int32
+		Leaf *BadLeaf
+	}
+
+	err := Precompile[Holder]()
+	if err == nil {
+		t.Fatal("Precompile() error = nil, want error from nested type")
+	}
+	if !strings.Contains(err.Error(), "BadLeaf") {
+		t.Errorf("error should name the nested type, got: %v", err)
+	}
+	if IsRegistered[Holder]() || IsRegistered[BadLeaf]() {
+		t.Error("types registered despite failure")
+	}
+}
+
+type AutoCPoint struct {
+	X int32
+	Y int32
+}
+
+type AutoCShape struct {
+	Origin AutoCPoint `cgocopy:"origin"`
+	Corner AutoCPoint `cgocopy:"corner"`
+}
+
+func autoCShapeInfo(originType string) CStructInfo {
+	return CStructInfo{
+		Name: "shape",
+		Size: 16,
+		Fields: []CFieldInfo{
+			{Name: "origin", Type: originType, Offset: 0, Size: 8},
+			{Name: "corner", Type: "struct", Offset: 8, Size: 8},
+		},
+	}
+}
+
+func TestPrecompileWithC_ResolvesNestedByCName(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	// C orders the point as {y, x}
+	AddCMetadata(CStructInfo{
+		Name: "AutoCPoint",
+		Size: 8,
+		Fields: []CFieldInfo{
+			{Name: "y", Type: "int32", Offset: 0, Size: 4},
+			{Name: "x", Type: "int32", Offset: 4, Size: 4},
+		},
+	})
+
+	if err := PrecompileWithC[AutoCShape](autoCShapeInfo("struct")); err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+	point := GetMetadata[AutoCPoint]()
+	if point == nil || point.CTypeName != "AutoCPoint" {
+		t.Fatalf("AutoCPoint metadata = %+v, want registered from C metadata", point)
+	}
+
+	// Untagged fields match positionally, so X reads C's y
+	c := [4]int32{1, 2, 3, 4}
+	got, err := Copy[AutoCShape](unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	want := AutoCShape{Origin: AutoCPoint{X: 1, Y: 2}, Corner: AutoCPoint{X: 3, Y: 4}}
+	if got != want {
+		t.Errorf("Copy() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPrecompileWithC_NestedCTypeFromField(t *testing.T) {
+	reg := NewRegistry()
+	reg.AddCMetadata(CStructInfo{
+		Name: "point_t",
+		Size: 8,
+		Fields: []CFieldInfo{
+			{Name: "x", Type: "int32", Offset: 0, Size: 4},
+			{Name: "y", Type: "int32", Offset: 4, Size: 4},
+		},
+	})
+
+	if err := reg.PrecompileWithC(reflect.TypeFor[AutoCShape](), autoCShapeInfo("struct point_t")); err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+	if metadata := reg.GetByCName("point_t"); metadata == nil || metadata.GoType != reflect.TypeFor[AutoCPoint]() {
+		t.Errorf("GetByCName(point_t) = %v, want AutoCPoint", metadata)
+	}
+}
+
+func TestPrecompileWithC_NestedRegisteredLater(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	// No catalog entry for AutoCPoint: the outer type registers on its own
+	if err := PrecompileWithC[AutoCShape](autoCShapeInfo("struct")); err != nil {
+		t.Fatalf("PrecompileWithC[AutoCShape]() error = %v", err)
+	}
+	if IsRegistered[AutoCPoint]() {
+		t.Fatal("AutoCPoint registered without C metadata")
+	}
+
+	err := ValidateStruct[AutoCShape]()
+	if err == nil || !strings.Contains(err.Error(), "AutoCPoint not registered") {
+		t.Errorf("ValidateStruct() error = %v, want unregistered AutoCPoint", err)
+	}
+	c := [4]int32{1, 2, 3, 4}
+	if _, err := Copy[AutoCShape](unsafe.Pointer(&c)); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Copy() error = %v, want ErrNotRegistered", err)
+	}
+
+	// Registering the nested type afterwards completes the registration
+	if err := PrecompileWithC[AutoCPoint](CStructInfo{
+		Name: "AutoCPoint",
+		Size: 8,
+		Fields: []CFieldInfo{
+			{Name: "x", Type: "int32", Offset: 0, Size: 4},
+			{Name: "y", Type: "int32", Offset: 4, Size: 4},
+		},
+	}); err != nil {
+		t.Fatalf("PrecompileWithC[AutoCPoint]() error = %v", err)
+	}
+	if err := ValidateStruct[AutoCShape](); err != nil {
+		t.Errorf("ValidateStruct() error = %v, want nil", err)
+	}
+	got, err := Copy[AutoCShape](unsafe.Pointer(&c))
+	want := AutoCShape{Origin: AutoCPoint{X: 1, Y: 2}, Corner: AutoCPoint{X: 3, Y: 4}}
+	if err != nil || got != want {
+		t.Errorf("Copy() = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func TestNestedCTypeName(t *testing.T) {
+	pointType := reflect.TypeFor[AutoCPoint]()
+	tests := []struct {
+		cType string
+		want  string
+	}{
+		{"", "AutoCPoint"},
+		{"struct", "AutoCPoint"},
+		{"pointer", "AutoCPoint"},
+		{"point_t", "point_t"},
+		{"point_t*", "point_t"},
+		{"struct point_t *", "point_t"},
+		{"point_t[]", "point_t"},
+		{"const point_t*", "point_t"},
+	}
+
+	for _, tt := range tests {
+		if got, err := nestedCTypeName(&FieldInfo{CType: tt.cType}, pointType); err != nil || got != tt.want {
+			t.Errorf("nestedCTypeName(%q) = %q, %v, want %q", tt.cType, got, err, tt.want)
+		}
+	}
+
+	for _, cType := range []string{"int32", "float[]", "string", "char*", "void*"} {
+		if _, err := nestedCTypeName(&FieldInfo{CType: cType}, pointType); !errors.Is(err, ErrMetadataNotFound) {
+			t.Errorf("nestedCTypeName(%q) error = %v, want ErrMetadataNotFound", cType, err)
+		}
+	}
+}
+
+func TestPrecompileWithC_NestedStructArray(t *testing.T) {
+	reg := NewRegistry()
+	reg.AddCMetadata(CStructInfo{
+		Name: "point_t",
+		Size: 8,
+		Fields: []CFieldInfo{
+			{Name: "x", Type: "int32", Offset: 0, Size: 4},
+			{Name: "y", Type: "int32", Offset: 4, Size: 4},
+		},
+	})
+
+	type Polyline struct {
+		Points [2]AutoCPoint
+	}
+	polylineInfo := func(pointsType string) CStructInfo {
+		return CStructInfo{Name: "polyline", Size: 16, Fields: []CFieldInfo{
+			{Name: "points", Type: pointsType, Size: 16, IsArray: true, ArrayLen: 2},
+		}}
+	}
+
+	// CGOCOPY_ARRAY_FIELD(polyline, points, point_t) emits "point_t[]"
+	if err := reg.PrecompileWithC(reflect.TypeFor[Polyline](), polylineInfo("point_t[]")); err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+	if metadata := reg.GetByCName("point_t"); metadata == nil || metadata.GoType != reflect.TypeFor[AutoCPoint]() {
+		t.Fatalf("GetByCName(point_t) = %v, want AutoCPoint registered", metadata)
+	}
+
+	c := [4]int32{1, 2, 3, 4}
+	got, err := CopyWith[Polyline](reg, unsafe.Pointer(&c))
+	want := Polyline{Points: [2]AutoCPoint{{X: 1, Y: 2}, {X: 3, Y: 4}}}
+	if err != nil || got != want {
+		t.Errorf("CopyWith() = %+v, %v, want %+v", got, err, want)
+	}
+
+	// A scalar element type cannot hold the struct
+	err = NewRegistry().PrecompileWithC(reflect.TypeFor[Polyline](), polylineInfo("int32[]"))
+	if !errors.Is(err, ErrMetadataNotFound) {
+		t.Errorf("PrecompileWithC(int32[]) error = %v, want ErrMetadataNotFound", err)
+	}
+}
+
+type GenericBox[T any] struct {
+	Value T
+	Count int32
+}
+
+func TestPrecompile_GenericInstantiations(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[GenericBox[int32]](); err != nil {
+		t.Fatalf("Precompile[GenericBox[int32]]() error = %v", err)
+	}
+	if err := Precompile[GenericBox[float64]](); err != nil {
+		t.Fatalf("Precompile[GenericBox[float64]]() error = %v", err)
+	}
+
+	pkg := reflect.TypeFor[SimpleStruct]().PkgPath()
+	intBox := GetMetadata[GenericBox[int32]]()
+	floatBox := GetMetadata[GenericBox[float64]]()
+	if intBox.TypeName != pkg+".GenericBox[int32]" || floatBox.TypeName != pkg+".GenericBox[float64]" {
+		t.Errorf("TypeNames = %q, %q, want distinct instantiation names", intBox.TypeName, floatBox.TypeName)
+	}
+
+	if got := globalRegistry.GetByCName(intBox.CTypeName); got != intBox {
+		t.Errorf("GetByCName(%q) = %v, want GenericBox[int32]", intBox.CTypeName, got)
+	}
+	if got := globalRegistry.GetByCName(floatBox.CTypeName); got != floatBox {
+		t.Errorf("GetByCName(%q) = %v, want GenericBox[float64]", floatBox.CTypeName, got)
+	}
+
+	src := GenericBox[float64]{Value: 2.5, Count: 3}
+	got, err := Copy[GenericBox[float64]](unsafe.Pointer(&src))
+	if err != nil || got != src {
+		t.Errorf("Copy() = %+v, %v, want %+v", got, err, src)
+	}
+}
+
+func TestPrecompileWithC_DuplicateCName(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	info := CStructInfo{
+		Name: "user",
+		Size: 4,
+		Fields: []CFieldInfo{
+			{Name: "id", Type: "int32", Offset: 0, Size: 4},
+		},
+	}
+	type AdminUser struct{ ID int32 }
+	type GuestUser struct{ ID int32 }
+
+	if err := PrecompileWithC[AdminUser](info); err != nil {
+		t.Fatalf("PrecompileWithC[AdminUser]() error = %v", err)
+	}
+	err := PrecompileWithC[GuestUser](info)
+	if !errors.Is(err, ErrDuplicateCName) {
+		t.Fatalf("PrecompileWithC[GuestUser]() error = %v, want ErrDuplicateCName", err)
+	}
+	if !strings.Contains(err.Error(), "AdminUser") {
+		t.Errorf("error should name the existing type, got: %v", err)
+	}
+
+	if IsRegistered[GuestUser]() {
+		t.Error("GuestUser registered despite C name collision")
+	}
+	if got := globalRegistry.GetByCName("user"); got == nil || got.GoType != reflect.TypeFor[AdminUser]() {
+		t.Errorf("GetByCName(user) = %v, want AdminUser", got)
+	}
+}
+
+// localRecord returns a function-local type named Record.
+func localRecord() reflect.Type {
+	type Record struct{ ID int32 }
+	return reflect.TypeFor[Record]()
+}
+
+func TestPrecompile_LocalTypesShareQualifiedName(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	// Both qualify to the same Go name, but neither has a C name to clash
+	type Record struct{ Name string }
+	first, second := localRecord(), reflect.TypeFor[Record]()
+	if qualifiedTypeName(first) != qualifiedTypeName(second) {
+		t.Fatalf("qualified names differ: %s, %s", qualifiedTypeName(first), qualifiedTypeName(second))
+	}
+
+	if err := globalRegistry.Precompile(first); err != nil {
+		t.Fatalf("Precompile(first) error = %v", err)
+	}
+	if err := globalRegistry.Precompile(second); err != nil {
+		t.Fatalf("Precompile(second) error = %v", err)
+	}
+	if !globalRegistry.IsRegistered(first) || !globalRegistry.IsRegistered(second) {
+		t.Error("both local types should be registered")
+	}
+
+	// The first registration keeps the lookup by name
+	if got := globalRegistry.GetByCName(qualifiedTypeName(first)); got == nil || got.GoType != first {
+		t.Errorf("GetByCName() = %v, want the first Record", got)
+	}
+}
+
+func TestPrecompileWithC_DuplicateCNameWithinDependencies(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	// The nested point's C metadata claims the outer struct's C name
+	AddCMetadata(CStructInfo{
+		Name: "shape",
+		Size: 8,
+		Fields: []CFieldInfo{
+			{Name: "x", Type: "int32", Offset: 0, Size: 4},
+			{Name: "y", Type: "int32", Offset: 4, Size: 4},
+		},
+	})
+
+	err := PrecompileWithC[AutoCShape](autoCShapeInfo("shape"))
+	if !errors.Is(err, ErrDuplicateCName) {
+		t.Fatalf("PrecompileWithC() error = %v, want ErrDuplicateCName", err)
+	}
+	if IsRegistered[AutoCShape]() || IsRegistered[AutoCPoint]() {
+		t.Error("types registered despite C name collision")
+	}
+}
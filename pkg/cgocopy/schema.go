@@ -0,0 +1,314 @@
+package cgocopy2
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"sort"
+)
+
+// SchemaVersion is the version of the registry schema written by Export.
+// Import rejects documents with a different version.
+const SchemaVersion = 1
+
+// Schema is the JSON document produced by Export: everything the registry
+// knows about each registered type's layout.
+type Schema struct {
+	Version int          `json:"version"`
+	Types   []SchemaType `json:"types"`
+}
+
+// SchemaType describes one registered type.
+type SchemaType struct {
+	// GoType is the package-qualified Go type name ("example.com/app.User"),
+	// used by Import to re-bind the entry to a Go type.
+	GoType string `json:"goType"`
+
+	// CName is the C struct name.
+	CName string `json:"cName"`
+
+	// Size is the C struct size (the Go size for Precompile'd types).
+	Size uintptr `json:"size"`
+
+	// Limits are the per-type resource limits, if any were set.
+	Limits *Limits `json:"limits,omitempty"`
+
+	// Fields is the field mapping derived at registration.
+	Fields []SchemaField `json:"fields"`
+
+	// C is the C metadata the type was registered with (absent for types
+	// registered without C metadata).
+	C *CStructInfo `json:"c,omitempty"`
+}
+
+// SchemaField describes one field mapping of a registered type.
+type SchemaField struct {
+	Name     string  `json:"name"`
+	CName    string  `json:"cName"`
+	CType    string  `json:"cType,omitempty"`
+	Kind     string  `json:"kind"`
+	GoType   string  `json:"goType"`
+	Offset   uintptr `json:"offset"`
+	Size     uintptr `json:"size"`
+	ArrayLen int     `json:"arrayLen,omitempty"`
+
+	// Required and Default are the NULL handling options of string and
+	// pointer fields; Default is absent without a default=... option.
+	Required bool    `json:"required,omitempty"`
+	Default  *string `json:"default,omitempty"`
+
+	// Map is the C layout of a map field's entries.
+	Map *MapLayout `json:"map,omitempty"`
+}
+
+// equal reports whether two schema fields describe the same mapping.
+func (f SchemaField) equal(other SchemaField) bool {
+	if (f.Default == nil) != (other.Default == nil) || (f.Default != nil && *f.Default != *other.Default) {
+		return false
+	}
+	if (f.Map == nil) != (other.Map == nil) || (f.Map != nil && *f.Map != *other.Map) {
+		return false
+	}
+	f.Default, f.Map = nil, nil
+	other.Default, other.Map = nil, nil
+	return f == other
+}
+
+// Export writes the global registry as a schema document.
+// See Registry.Export.
+func Export() ([]byte, error) {
+	return globalRegistry.Export()
+}
+
+// Import registers types in the global registry from a schema document.
+// See Registry.Import.
+func Import(data []byte, types ...reflect.Type) error {
+	return globalRegistry.Import(data, types...)
+}
+
+// Schema returns the registry contents as a Schema, with types sorted by Go
+// type name and fields in registration order.
+func (r *Registry) Schema() Schema {
+	all := r.all()
+	schema := Schema{Version: SchemaVersion, Types: make([]SchemaType, 0, len(all))}
+
+	for _, metadata := range all {
+		st := SchemaType{
+			GoType: qualifiedTypeName(metadata.GoType),
+			CName:  metadata.CTypeName,
+			Size:   metadata.Size,
+			Fields: make([]SchemaField, 0, len(metadata.Fields)),
+		}
+		if metadata.Limits != (Limits{}) {
+			limits := metadata.Limits
+			st.Limits = &limits
+		}
+		if metadata.CInfo != nil {
+			st.C = cloneCStructInfo(*metadata.CInfo)
+		}
+		for i := range metadata.Fields {
+			st.Fields = append(st.Fields, newSchemaField(&metadata.Fields[i]))
+		}
+		schema.Types = append(schema.Types, st)
+	}
+
+	sort.Slice(schema.Types, func(i, j int) bool {
+		return schema.Types[i].GoType < schema.Types[j].GoType
+	})
+	return schema
+}
+
+// newSchemaField converts field metadata to its schema form.
+func newSchemaField(field *FieldInfo) SchemaField {
+	sf := SchemaField{
+		Name:     field.Name,
+		CName:    field.CName,
+		CType:    field.CType,
+		Kind:     field.Type.String(),
+		GoType:   field.ReflectType.String(),
+		Offset:   field.Offset,
+		Size:     field.Size,
+		ArrayLen: field.ArrayLen,
+		Required: field.Required,
+	}
+	if field.HasDefault {
+		text := field.Default
+		sf.Default = &text
+	}
+	if field.Map != nil {
+		layout := *field.Map
+		sf.Map = &layout
+	}
+	return sf
+}
+
+// Export writes the registry as an indented JSON schema document (see
+// Schema), for inspecting or archiving the layouts it uses.
+func (r *Registry) Export() ([]byte, error) {
+	return json.MarshalIndent(r.Schema(), "", "  ")
+}
+
+// Import registers types from a schema document written by Export,
+// re-binding document entries to the given Go types by package-qualified
+// type name. This lets a program ship precomputed C layouts instead of
+// calling cgo metadata getters at startup.
+//
+// Types with C metadata in the document are registered with PrecompileWithC
+// (nested types resolve against the document's C metadata), then the others
+// with Precompile. Types already registered are kept. Every given type's
+// derived layout, including its NULL options and map layouts, must then
+// match the document exactly, otherwise Import fails with ErrSchemaMismatch;
+// document entries not bound to a given type are ignored.
+//
+// Import is atomic: if any type fails, including because r is sealed,
+// nothing is registered and no limits or C metadata are added.
+// Importing into a sealed registry only verifies the given types against the
+// document: it succeeds when nothing would change, and adds nothing to the C
+// metadata catalog.
+//
+// Example:
+//
+//	//go:embed layouts.json
+//	var layouts []byte
+//
+//	func init() {
+//	    err := cgocopy2.Import(layouts, reflect.TypeFor[User](), reflect.TypeFor[Address]())
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+func (r *Registry) Import(data []byte, types ...reflect.Type) error {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return fmt.Errorf("%w: %v", ErrSchemaMismatch, err)
+	}
+	if schema.Version != SchemaVersion {
+		return fmt.Errorf("%w: unsupported schema version %d (want %d)",
+			ErrSchemaMismatch, schema.Version, SchemaVersion)
+	}
+
+	byName := make(map[string]*SchemaType, len(schema.Types))
+	for i := range schema.Types {
+		byName[schema.Types[i].GoType] = &schema.Types[i]
+	}
+
+	// Bind the given types to their document entries
+	bound := make([]reflect.Type, 0, len(types))
+	for _, goType := range types {
+		if goType.Kind() == reflect.Ptr {
+			goType = goType.Elem()
+		}
+		if _, ok := byName[qualifiedTypeName(goType)]; !ok {
+			return newRegistrationError(goType, "type not found in schema", ErrSchemaMismatch)
+		}
+		bound = append(bound, goType)
+	}
+
+	// Register into a scratch copy of r and commit only once every type has
+	// been imported
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	scratch := r.cloneLocked()
+	for _, st := range schema.Types {
+		if st.C != nil {
+			scratch.AddCMetadata(*st.C)
+		}
+	}
+	if err := scratch.importTypes(bound, byName); err != nil {
+		return err
+	}
+	return r.commitLocked(scratch)
+}
+
+// importTypes registers the bound types in r from their schema entries,
+// checks them against the schema and applies the schema's limits.
+func (r *Registry) importTypes(bound []reflect.Type, byName map[string]*SchemaType) error {
+	// C types first, so that registering a Go-layout type does not register
+	// a nested type with the Go layout that the document binds to C metadata
+	for _, withC := range []bool{true, false} {
+		for _, goType := range bound {
+			st := byName[qualifiedTypeName(goType)]
+			if (st.C != nil) != withC || r.IsRegistered(goType) {
+				continue
+			}
+
+			var err error
+			if st.C != nil {
+				err = r.PrecompileWithC(goType, *st.C)
+			} else {
+				err = r.Precompile(goType)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, goType := range bound {
+		st := byName[qualifiedTypeName(goType)]
+		metadata := r.Get(goType)
+		if err := checkSchemaType(metadata, st); err != nil {
+			return newRegistrationError(goType, "registered layout differs from schema", err)
+		}
+		if st.Limits != nil && metadata.Limits != *st.Limits {
+			if err := r.SetTypeLimits(goType, *st.Limits); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// cloneLocked returns an unsealed registry holding the same registrations
+// and C metadata catalog as r. The caller must hold r.mu.
+func (r *Registry) cloneLocked() *Registry {
+	return &Registry{
+		metadata: maps.Clone(r.metadata),
+		cTypeMap: maps.Clone(r.cTypeMap),
+		cInfos:   maps.Clone(r.cInfos),
+	}
+}
+
+// commitLocked replaces the contents of r with those of scratch, a clone of
+// r with registrations added. A sealed r only accepts a scratch registry
+// without new or changed types, and is left untouched: the C metadata
+// catalog of scratch is discarded. The caller must hold r.mu.
+func (r *Registry) commitLocked(scratch *Registry) error {
+	if r.sealed.Load() != nil {
+		for goType, metadata := range scratch.metadata {
+			if r.metadata[goType] != metadata {
+				return newRegistrationError(goType, "registry is sealed", ErrRegistrySealed)
+			}
+		}
+		return nil
+	}
+
+	r.metadata = scratch.metadata
+	r.cTypeMap = scratch.cTypeMap
+	r.cInfos = scratch.cInfos
+	return nil
+}
+
+// checkSchemaType compares registered metadata with its schema entry.
+func checkSchemaType(metadata *StructMetadata, st *SchemaType) error {
+	if metadata.CTypeName != st.CName || metadata.Size != st.Size {
+		return fmt.Errorf("%w: C struct %s (size %d), schema has %s (size %d)",
+			ErrSchemaMismatch, metadata.CTypeName, metadata.Size, st.CName, st.Size)
+	}
+	if len(metadata.Fields) != len(st.Fields) {
+		return fmt.Errorf("%w: %d fields, schema has %d",
+			ErrSchemaMismatch, len(metadata.Fields), len(st.Fields))
+	}
+	for i := range metadata.Fields {
+		if got := newSchemaField(&metadata.Fields[i]); !got.equal(st.Fields[i]) {
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(st.Fields[i])
+			return fmt.Errorf("%w: field %s is %s, schema has %s",
+				ErrSchemaMismatch, got.Name, gotJSON, wantJSON)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package cgocopy2
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func newSchemaTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+
+	reg := NewRegistry()
+	reg.AddCMetadata(CStructInfo{
+		Name: "AutoCPoint",
+		Size: 8,
+		Fields: []CFieldInfo{
+			{Name: "y", Type: "int32", Offset: 0, Size: 4},
+			{Name: "x", Type: "int32", Offset: 4, Size: 4},
+		},
+	})
+	if err := reg.PrecompileWithC(reflect.TypeFor[AutoCShape](), autoCShapeInfo("struct")); err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+	if err := reg.Precompile(reflect.TypeFor[CopySimple]()); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+	reg.SetLimits(reflect.TypeFor[CopySimple](), Limits{MaxDepth: 3})
+	return reg
+}
+
+func TestRegistry_ExportImportRoundTrip(t *testing.T) {
+	src := newSchemaTestRegistry(t)
+	data, err := src.Export()
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	dst := NewRegistry()
+	err = dst.Import(data, reflect.TypeFor[AutoCShape](), reflect.TypeFor[CopySimple]())
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(dst.Schema(), src.Schema()) {
+		t.Errorf("imported schema differs:\n got %+v\nwant %+v", dst.Schema(), src.Schema())
+	}
+	if got := dst.Get(reflect.TypeFor[CopySimple]()).Limits; got.MaxDepth != 3 {
+		t.Errorf("imported Limits = %+v, want MaxDepth 3", got)
+	}
+
+	// Nested type registered from the document's C metadata
+	c := [4]int32{1, 2, 3, 4}
+	got, err := CopyWith[AutoCShape](dst, unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("CopyWith() error = %v", err)
+	}
+	if got.Origin != (AutoCPoint{X: 1, Y: 2}) {
+		t.Errorf("Origin = %+v, want {1 2}", got.Origin)
+	}
+}
+
+func TestRegistry_ExportDocument(t *testing.T) {
+	data, err := newSchemaTestRegistry(t).Export()
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	var doc struct {
+		Version int `json:"version"`
+		Types   []struct {
+			GoType string `json:"goType"`
+			CName  string `json:"cName"`
+			Fields []struct {
+				Name   string  `json:"name"`
+				Kind   string  `json:"kind"`
+				Offset uintptr `json:"offset"`
+			} `json:"fields"`
+			C *struct {
+				Fields []struct {
+					Name string `json:"name"`
+				} `json:"fields"`
+			} `json:"c"`
+		} `json:"types"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if doc.Version != SchemaVersion {
+		t.Errorf("version = %d, want %d", doc.Version, SchemaVersion)
+	}
+	var names []string
+	for _, st := range doc.Types {
+		names = append(names, st.GoType)
+	}
+	pkg := reflect.TypeFor[CopySimple]().PkgPath()
+	want := []string{pkg + ".AutoCPoint", pkg + ".AutoCShape", pkg + ".CopySimple"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("types = %v, want %v", names, want)
+	}
+
+	shape := doc.Types[1]
+	if shape.C == nil || len(shape.C.Fields) != 2 {
+		t.Errorf("AutoCShape C metadata = %+v, want 2 C fields", shape.C)
+	}
+	if f := shape.Fields[1]; f.Name != "Corner" || f.Kind != "Struct" || f.Offset != 8 {
+		t.Errorf("AutoCShape.Corner = %+v, want Struct at offset 8", f)
+	}
+	if doc.Types[2].C != nil {
+		t.Error("CopySimple has C metadata, want none (registered with Precompile)")
+	}
+}
+
+func TestRegistry_ImportErrors(t *testing.T) {
+	data, err := newSchemaTestRegistry(t).Export()
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	t.Run("version", func(t *testing.T) {
+		bad := strings.Replace(string(data), `"version": 1`, `"version": 99`, 1)
+		err := NewRegistry().Import([]byte(bad), reflect.TypeFor[CopySimple]())
+		if !errors.Is(err, ErrSchemaMismatch) {
+			t.Errorf("Import() error = %v, want ErrSchemaMismatch", err)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		if err := NewRegistry().Import([]byte("{")); !errors.Is(err, ErrSchemaMismatch) {
+			t.Errorf("Import() error = %v, want ErrSchemaMismatch", err)
+		}
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		err := NewRegistry().Import(data, reflect.TypeFor[CopyNested]())
+		if !errors.Is(err, ErrSchemaMismatch) {
+			t.Errorf("Import() error = %v, want ErrSchemaMismatch", err)
+		}
+	})
+
+	t.Run("layout drift", func(t *testing.T) {
+		var schema Schema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		for i := range schema.Types {
+			if strings.HasSuffix(schema.Types[i].GoType, ".CopySimple") {
+				schema.Types[i].Fields[1].Offset += 4
+			}
+		}
+		drifted, _ := json.Marshal(schema)
+
+		err := NewRegistry().Import(drifted, reflect.TypeFor[CopySimple]())
+		if !errors.Is(err, ErrSchemaMismatch) || !strings.Contains(err.Error(), "Count") {
+			t.Errorf("Import() error = %v, want ErrSchemaMismatch naming Count", err)
+		}
+	})
+}
+
+type SchemaConfig struct {
+	Options map[string]string `cgocopy:"keys,values=values,len=n,dup=last"`
+}
+
+type SchemaPointHolder struct {
+	ID    int32
+	Point AutoCPoint
+}
+
+func TestRegistry_ExportImportExportIdentical(t *testing.T) {
+	src := NewRegistry()
+	if err := src.Precompile(reflect.TypeFor[NullableParent]()); err != nil {
+		t.Fatalf("Precompile(NullableParent) error = %v", err)
+	}
+	var c cNullableRecord
+	if err := src.PrecompileWithC(reflect.TypeFor[NullableRecord](), CStructInfo{
+		Name: "NullableRecord",
+		Size: unsafe.Sizeof(c),
+		Fields: []CFieldInfo{
+			{Name: "nick", Type: "string", Offset: unsafe.Offsetof(c.Nick), Size: unsafe.Sizeof(c.Nick), IsPointer: true},
+			{Name: "title", Type: "string", Offset: unsafe.Offsetof(c.Title), Size: unsafe.Sizeof(c.Title), IsPointer: true},
+			{Name: "retries", Type: "int32", Offset: unsafe.Offsetof(c.Retries), Size: unsafe.Sizeof(c.Retries), IsPointer: true},
+			{Name: "parent", Type: "struct", Offset: unsafe.Offsetof(c.Parent), Size: unsafe.Sizeof(c.Parent), IsPointer: true},
+		},
+	}); err != nil {
+		t.Fatalf("PrecompileWithC(NullableRecord) error = %v", err)
+	}
+	if err := src.PrecompileWithC(reflect.TypeFor[SchemaConfig](), keyValueConfigInfo()); err != nil {
+		t.Fatalf("PrecompileWithC(SchemaConfig) error = %v", err)
+	}
+
+	first, err := src.Export()
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	for _, want := range []string{`"default": "untitled"`, `"default": ""`, `"map": {`, `"lastWins": true`} {
+		if !strings.Contains(string(first), want) {
+			t.Errorf("Export() document missing %s", want)
+		}
+	}
+
+	dst := NewRegistry()
+	err = dst.Import(first, reflect.TypeFor[NullableRecord](), reflect.TypeFor[NullableParent](), reflect.TypeFor[SchemaConfig]())
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	second, err := dst.Export()
+	if err != nil {
+		t.Fatalf("Export() after Import error = %v", err)
+	}
+	if string(second) != string(first) {
+		t.Errorf("Export() after Import differs:\n got %s\nwant %s", second, first)
+	}
+}
+
+func TestRegistry_ImportCTypesFirst(t *testing.T) {
+	// The Go-layout holder nests a type the document binds to C metadata
+	src := NewRegistry()
+	if err := src.PrecompileWithC(reflect.TypeFor[AutoCPoint](), CStructInfo{
+		Name: "AutoCPoint",
+		Size: 8,
+		Fields: []CFieldInfo{
+			{Name: "y", Type: "int32", Offset: 0, Size: 4},
+			{Name: "x", Type: "int32", Offset: 4, Size: 4},
+		},
+	}); err != nil {
+		t.Fatalf("PrecompileWithC(AutoCPoint) error = %v", err)
+	}
+	if err := src.Precompile(reflect.TypeFor[SchemaPointHolder]()); err != nil {
+		t.Fatalf("Precompile(SchemaPointHolder) error = %v", err)
+	}
+	data, err := src.Export()
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	dst := NewRegistry()
+	if err := dst.Import(data, reflect.TypeFor[SchemaPointHolder](), reflect.TypeFor[AutoCPoint]()); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if point := dst.Get(reflect.TypeFor[AutoCPoint]()); point == nil || point.CInfo == nil {
+		t.Errorf("AutoCPoint metadata = %+v, want registered with C metadata", point)
+	}
+}
+
+func TestRegistry_ImportIsAtomic(t *testing.T) {
+	data, err := newSchemaTestRegistry(t).Export()
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	for i := range schema.Types {
+		if strings.HasSuffix(schema.Types[i].GoType, ".CopySimple") {
+			schema.Types[i].Fields[1].Offset += 4
+		}
+	}
+	drifted, _ := json.Marshal(schema)
+
+	// AutoCShape (and its nested AutoCPoint) import, CopySimple does not
+	dst := NewRegistry()
+	err = dst.Import(drifted, reflect.TypeFor[AutoCShape](), reflect.TypeFor[CopySimple]())
+	if !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("Import() error = %v, want ErrSchemaMismatch", err)
+	}
+	if n := dst.Count(); n != 0 {
+		t.Errorf("Count() = %d after failed Import, want 0: %v", n, dst.RegisteredTypes())
+	}
+	if _, ok := dst.lookupCMetadata("AutoCPoint"); ok {
+		t.Error("C metadata added to the catalog by a failed Import")
+	}
+}
+
+func TestRegistry_ImportSealed(t *testing.T) {
+	data, err := newSchemaTestRegistry(t).Export()
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	// Already registered and sealed without the schema's limits
+	dst := NewRegistry()
+	if err := dst.Precompile(reflect.TypeFor[CopySimple]()); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+	dst.Seal()
+
+	err = dst.Import(data, reflect.TypeFor[CopySimple]())
+	if !errors.Is(err, ErrRegistrySealed) {
+		t.Errorf("Import() error = %v, want ErrRegistrySealed", err)
+	}
+	if got := dst.Get(reflect.TypeFor[CopySimple]()).Limits; got != (Limits{}) {
+		t.Errorf("Limits = %+v after failed Import, want none", got)
+	}
+
+	// Nothing to change: importing into a sealed registry only verifies
+	sealed := NewRegistry()
+	if err := sealed.Import(data, reflect.TypeFor[CopySimple]()); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	sealed.Seal()
+	if err := sealed.Import(data, reflect.TypeFor[CopySimple]()); err != nil {
+		t.Errorf("Import() into sealed registry with nothing to change error = %v", err)
+	}
+
+	// Nor does it catalog the document's C metadata (AutoCShape, AutoCPoint)
+	bare := NewRegistry()
+	if err := bare.Precompile(reflect.TypeFor[CopySimple]()); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+	bare.SetLimits(reflect.TypeFor[CopySimple](), Limits{MaxDepth: 3})
+	bare.Seal()
+	if err := bare.Import(data, reflect.TypeFor[CopySimple]()); err != nil {
+		t.Fatalf("Import() into sealed registry with nothing to change error = %v", err)
+	}
+	if n := len(bare.cInfos); n != 0 {
+		t.Errorf("sealed Import added %d C metadata entries, want none", n)
+	}
+}
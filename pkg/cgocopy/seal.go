@@ -0,0 +1,112 @@
+package cgocopy2
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// registrySnapshot is the immutable view of a sealed registry. It is read
+// without locks; nothing in it is modified after Seal publishes it.
+type registrySnapshot struct {
+	metadata map[reflect.Type]*StructMetadata
+	cTypeMap map[string]reflect.Type
+}
+
+// Seal makes the global registry immutable. See Registry.Seal.
+func Seal() {
+	globalRegistry.Seal()
+}
+
+// Finalize is an alias for Seal, matching the v1 API.
+func Finalize() {
+	globalRegistry.Seal()
+}
+
+// IsSealed reports whether the global registry has been sealed.
+func IsSealed() bool {
+	return globalRegistry.IsSealed()
+}
+
+// Seal makes the registry immutable. Call it once all types are registered,
+// typically at the end of init.
+//
+// After sealing, Precompile and PrecompileWithC fail with ErrRegistrySealed,
+// lookups read an immutable snapshot without taking any lock, and every
+// field's nested struct metadata (nested structs, array/slice elements,
+// pointer and Lazy targets) is resolved once, so copies skip the per-field
+// registry lookup. Sealing twice is a no-op; Clear unseals.
+//
+// Example:
+//
+//	func init() {
+//	    cgocopy2.Precompile[Address]()
+//	    cgocopy2.Precompile[User]()
+//	    cgocopy2.Seal()
+//	}
+func (r *Registry) Seal() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sealed.Load() != nil {
+		return
+	}
+
+	// Copy the metadata so resolving nested pointers never mutates values
+	// handed out before sealing
+	snapshot := &registrySnapshot{
+		metadata: make(map[reflect.Type]*StructMetadata, len(r.metadata)),
+		cTypeMap: maps.Clone(r.cTypeMap),
+	}
+	for goType, metadata := range r.metadata {
+		sealed := *metadata
+		sealed.Fields = slices.Clone(metadata.Fields)
+		snapshot.metadata[goType] = &sealed
+	}
+	for _, metadata := range snapshot.metadata {
+		for i := range metadata.Fields {
+			field := &metadata.Fields[i]
+			if nestedType := nestedStructType(field); nestedType != nil {
+				field.nested = snapshot.metadata[nestedType]
+			}
+		}
+	}
+
+	r.metadata = snapshot.metadata
+	r.cTypeMap = snapshot.cTypeMap
+	r.sealed.Store(snapshot)
+}
+
+// Finalize is an alias for Seal, matching the v1 API.
+func (r *Registry) Finalize() {
+	r.Seal()
+}
+
+// IsSealed reports whether Seal has been called.
+func (r *Registry) IsSealed() bool {
+	return r.sealed.Load() != nil
+}
+
+// nestedStructType returns the struct type whose metadata a field needs at
+// copy time: the field's own type for nested structs, or the element type of
+// arrays, slices, pointers and Lazy fields. It returns nil otherwise.
+func nestedStructType(field *FieldInfo) reflect.Type {
+	switch field.Type {
+	case FieldTypeStruct:
+		return field.ReflectType
+	case FieldTypeArray, FieldTypeSlice, FieldTypePointer, FieldTypeLazy:
+		if field.ElemType != nil && field.ElemType.Kind() == reflect.Struct {
+			return field.ElemType
+		}
+	}
+	return nil
+}
+
+// nestedMetadata returns the metadata for the struct type t reached through
+// field, using the pointer resolved at Seal when available.
+func (s *copyState) nestedMetadata(field *FieldInfo, t reflect.Type) *StructMetadata {
+	if field.nested != nil {
+		return field.nested
+	}
+	return s.registry.Get(t)
+}
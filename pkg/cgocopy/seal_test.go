@@ -0,0 +1,181 @@
+package cgocopy2
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+type SealNode struct {
+	Value int32
+	Next  *SealNode
+}
+
+func TestSeal_RejectsRegistration(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[CopySimple](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+	Seal()
+	if !IsSealed() {
+		t.Fatal("IsSealed() = false after Seal()")
+	}
+
+	if err := Precompile[CopyNested](); !errors.Is(err, ErrRegistrySealed) {
+		t.Errorf("Precompile() after Seal error = %v, want ErrRegistrySealed", err)
+	}
+	if err := PrecompileWithC[CopyNested](CStructInfo{}); !errors.Is(err, ErrRegistrySealed) {
+		t.Errorf("PrecompileWithC() after Seal error = %v, want ErrRegistrySealed", err)
+	}
+	if err := SetTypeLimits[CopySimple](Limits{MaxDepth: 1}); !errors.Is(err, ErrRegistrySealed) {
+		t.Errorf("SetTypeLimits() after Seal error = %v, want ErrRegistrySealed", err)
+	}
+
+	if err := globalRegistry.Register(reflect.TypeFor[CopyNested](), &StructMetadata{}); !errors.Is(err, ErrRegistrySealed) {
+		t.Errorf("Register() after Seal error = %v, want ErrRegistrySealed", err)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("MustRegister() after Seal did not panic")
+		}
+	}()
+	globalRegistry.MustRegister(reflect.TypeFor[CopyNested](), &StructMetadata{})
+}
+
+func TestSeal_ResolvesNestedMetadata(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[CopySimple](); err != nil {
+		t.Fatalf("Precompile[CopySimple]() error = %v", err)
+	}
+	if err := Precompile[CopyNested](); err != nil {
+		t.Fatalf("Precompile[CopyNested]() error = %v", err)
+	}
+	if err := Precompile[SealNode](); err != nil {
+		t.Fatalf("Precompile[SealNode]() error = %v", err)
+	}
+	before := GetMetadata[CopyNested]()
+
+	Finalize()
+
+	after := GetMetadata[CopyNested]()
+	if after.Fields[1].nested != GetMetadata[CopySimple]() {
+		t.Error("nested metadata of CopyNested.Profile not resolved to the sealed CopySimple")
+	}
+	if before.Fields[1].nested != nil {
+		t.Error("Seal mutated metadata handed out before sealing")
+	}
+
+	// Self-referential types resolve to themselves
+	node := GetMetadata[SealNode]()
+	if node.Fields[1].nested != node {
+		t.Error("SealNode.Next not resolved to SealNode metadata")
+	}
+
+	src := CopyNested{ID: 1, Profile: CopySimple{ID: 2, Score: 1.5}}
+	got, err := Copy[CopyNested](unsafe.Pointer(&src))
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if got != src {
+		t.Errorf("Copy() = %+v, want %+v", got, src)
+	}
+
+	tail := SealNode{Value: 2}
+	head := SealNode{Value: 1, Next: &tail}
+	list, err := Copy[SealNode](unsafe.Pointer(&head))
+	if err != nil {
+		t.Fatalf("Copy[SealNode]() error = %v", err)
+	}
+	if list.Next == nil || list.Next.Value != 2 {
+		t.Errorf("Copy[SealNode]() = %+v, want Next.Value 2", list)
+	}
+}
+
+func TestSeal_UnresolvedNestedStillReportsNotRegistered(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	registerWithoutDependencies[CopyNested](t)
+	Seal()
+
+	src := CopyNested{}
+	if _, err := Copy[CopyNested](unsafe.Pointer(&src)); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Copy() error = %v, want ErrNotRegistered", err)
+	}
+}
+
+func TestSeal_ClearUnseals(t *testing.T) {
+	reg := NewRegistry()
+	reg.Seal()
+	reg.Clear()
+	if reg.IsSealed() {
+		t.Fatal("IsSealed() = true after Clear()")
+	}
+	if err := reg.Precompile(reflect.TypeFor[CopySimple]()); err != nil {
+		t.Errorf("Precompile() after Clear error = %v", err)
+	}
+}
+
+func TestSeal_ConcurrentCopies(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[CopySimple](); err != nil {
+		t.Fatalf("Precompile[CopySimple]() error = %v", err)
+	}
+	if err := Precompile[CopyNested](); err != nil {
+		t.Fatalf("Precompile[CopyNested]() error = %v", err)
+	}
+
+	src := CopyNested{ID: 1, Profile: CopySimple{ID: 2}}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i == 0 {
+				Seal()
+			}
+			for j := 0; j < 100; j++ {
+				if _, err := Copy[CopyNested](unsafe.Pointer(&src)); err != nil {
+					t.Errorf("Copy() error = %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func BenchmarkCopy_NestedParallel(b *testing.B) {
+	for _, sealed := range []bool{false, true} {
+		name := "Unsealed"
+		if sealed {
+			name = "Sealed"
+		}
+		b.Run(name, func(b *testing.B) {
+			Reset()
+			defer Reset()
+			Precompile[CopySimple]()
+			Precompile[CopyNested]()
+			if sealed {
+				Seal()
+			}
+
+			src := CopyNested{ID: 1, Profile: CopySimple{ID: 2}}
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, _ = Copy[CopyNested](unsafe.Pointer(&src))
+				}
+			})
+		})
+	}
+}
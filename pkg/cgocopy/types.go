@@ -3,8 +3,10 @@
 package cgocopy2
 
 import (
+	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 )
 
 // FieldType represents the type of a struct field.
@@ -33,6 +35,19 @@ const (
 
 	// FieldTypePointer represents pointer types.
 	FieldTypePointer
+
+	// FieldTypeOpaque represents raw C pointer values (void*, callbacks,
+	// context handles) held in unsafe.Pointer or uintptr fields.
+	// The address is copied verbatim and never dereferenced.
+	FieldTypeOpaque
+
+	// FieldTypeMap represents Go map types filled from C key/value arrays
+	// (see MapLayout).
+	FieldTypeMap
+
+	// FieldTypeLazy represents Lazy[T] fields, which record a C struct
+	// pointer and copy the pointee on first access.
+	FieldTypeLazy
 )
 
 // String returns the string representation of a FieldType.
@@ -50,6 +65,12 @@ func (ft FieldType) String() string {
 		return "Slice"
 	case FieldTypePointer:
 		return "Pointer"
+	case FieldTypeOpaque:
+		return "Opaque"
+	case FieldTypeMap:
+		return "Map"
+	case FieldTypeLazy:
+		return "Lazy"
 	default:
 		return "Invalid"
 	}
@@ -63,6 +84,9 @@ type FieldInfo struct {
 	// CName is the field name in the C struct (may differ due to tags).
 	CName string
 
+	// CType is the C type name from C metadata ("" without C metadata).
+	CType string
+
 	// Type is the category of the field (primitive, string, struct, etc).
 	Type FieldType
 
@@ -77,24 +101,97 @@ type FieldInfo struct {
 	Skip bool
 
 	// Index is the field index in the struct (for reflect.StructField access).
+	// For fields flattened from an embedded struct it is the index of the
+	// outermost embedded field.
 	Index int
 
+	// IndexPath is the reflect index sequence for fields flattened from
+	// embedded structs (nil for direct fields).
+	IndexPath []int
+
 	// ReflectType is the reflect.Type of the field for type checking.
 	ReflectType reflect.Type
 
 	// ArrayLen is the length for array types (0 for non-arrays).
 	ArrayLen int
 
-	// ElemType is the element type for arrays, slices, and pointers.
+	// ElemType is the element type for arrays, slices, and pointers,
+	// and the value type for maps.
 	ElemType reflect.Type
+
+	// KeyType is the key type for map fields (nil for non-maps).
+	KeyType reflect.Type
+
+	// Map describes the C layout backing a map field (nil for non-maps).
+	Map *MapLayout
+
+	// Required fails the copy with ErrRequiredField when the C pointer of a
+	// string or pointer field is NULL (tag option `required`).
+	Required bool
+
+	// HasDefault indicates that a NULL C pointer is replaced by Default
+	// (tag option `default=...`).
+	HasDefault bool
+
+	// Default is the text of the default=... tag option.
+	Default string
+
+	// defaultValue is Default parsed into the field's Go type.
+	defaultValue reflect.Value
+
+	// nested is the metadata of the struct reached through this field (see
+	// nestedStructType), resolved when the registry is sealed.
+	nested *StructMetadata
+}
+
+// MapLayout describes where the entries of a Go map field live in C memory.
+//
+// Two shapes are supported:
+//   - paired arrays: `K* keys; V* values; size_t n;`
+//   - array of pair structs: `struct { K key; V value; }* pairs; size_t n;`
+//
+// All offsets are relative to the start of the enclosing C struct.
+type MapLayout struct {
+	// Paired is true for separate key and value arrays, false for an
+	// array of {key, value} structs.
+	Paired bool `json:"paired,omitempty"`
+
+	// KeysOffset is the offset of the keys pointer (or the pairs pointer).
+	KeysOffset uintptr `json:"keysOffset"`
+
+	// ValuesOffset is the offset of the values pointer (paired arrays only).
+	ValuesOffset uintptr `json:"valuesOffset,omitempty"`
+
+	// LenOffset is the offset of the integer entry count.
+	LenOffset uintptr `json:"lenOffset"`
+
+	// LenSize is the size in bytes of the entry count field.
+	LenSize uintptr `json:"lenSize"`
+
+	// KeyStride is the distance between consecutive keys in C memory.
+	KeyStride uintptr `json:"keyStride"`
+
+	// ValueStride is the distance between consecutive values in C memory.
+	ValueStride uintptr `json:"valueStride"`
+
+	// ValueOffset is the offset of the value within a pair struct
+	// (array of pair structs only).
+	ValueOffset uintptr `json:"valueOffset,omitempty"`
+
+	// LastWins resolves duplicate keys by keeping the last entry instead
+	// of failing the copy with ErrDuplicateKey.
+	LastWins bool `json:"lastWins,omitempty"`
 }
 
 // StructMetadata contains all metadata needed to copy a struct type.
 type StructMetadata struct {
-	// TypeName is the fully qualified Go type name.
+	// TypeName is the package-qualified Go type name
+	// (e.g. "example.com/app.User" or "example.com/app.Box[int32]").
 	TypeName string
 
-	// CTypeName is the C struct name (without "struct" prefix).
+	// CTypeName is the C struct name (without "struct" prefix). Types
+	// registered without C metadata use TypeName. C names are unique within
+	// a registry.
 	CTypeName string
 
 	// Fields contains metadata for each field in the struct.
@@ -112,15 +209,40 @@ type StructMetadata struct {
 	// IsPrimitive indicates if this is a simple primitive type
 	// (used for FastCopy optimization).
 	IsPrimitive bool
+
+	// HasHooks indicates that *GoType implements AfterCopier or
+	// CopyValidator, so Copy must call its post-copy hooks.
+	HasHooks bool
+
+	// Limits holds the per-type resource limits set via SetTypeLimits.
+	// Zero fields fall back to the global defaults.
+	Limits Limits
+
+	// CInfo is the C metadata the type was registered with
+	// (nil for types registered with Precompile).
+	CInfo *CStructInfo
 }
 
 // Registry is the thread-safe registry for struct metadata.
+//
+// The package-level functions (Precompile, Copy, ValidateStruct, ...) use a
+// global registry. Independent registries created with NewRegistry are used
+// through the registry-scoped API (reg.Precompile, CopyWith, reg.Validate,
+// ...), and nested types are always resolved in the same registry.
 type Registry struct {
 	mu       sync.RWMutex
 	metadata map[reflect.Type]*StructMetadata
 
 	// cTypeMap maps C type names to reflect.Type for faster lookup.
 	cTypeMap map[string]reflect.Type
+
+	// cInfos is the catalog of C struct metadata by C type name, used to
+	// register nested types (see AddCMetadata).
+	cInfos map[string]CStructInfo
+
+	// sealed holds the immutable snapshot read without locks once Seal has
+	// been called (nil before).
+	sealed atomic.Pointer[registrySnapshot]
 }
 
 // NewRegistry creates a new empty Registry.
@@ -128,31 +250,87 @@ func NewRegistry() *Registry {
 	return &Registry{
 		metadata: make(map[reflect.Type]*StructMetadata),
 		cTypeMap: make(map[string]reflect.Type),
+		cInfos:   make(map[string]CStructInfo),
 	}
 }
 
-// Register adds struct metadata to the registry.
-// This is called internally by Precompile.
-func (r *Registry) Register(goType reflect.Type, metadata *StructMetadata) {
+// Register adds struct metadata to the registry. It returns an error
+// wrapping ErrRegistrySealed once the registry has been sealed, and one
+// wrapping ErrDuplicateCName if the C name is registered for another type.
+func (r *Registry) Register(goType reflect.Type, metadata *StructMetadata) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.sealed.Load() != nil {
+		return newRegistrationError(goType, "registry is sealed", ErrRegistrySealed)
+	}
+	if err := r.checkCName(goType, metadata); err != nil {
+		return err
+	}
+
 	r.metadata[goType] = metadata
-	r.cTypeMap[metadata.CTypeName] = goType
+	r.mapCName(goType, metadata)
+	return nil
+}
+
+// MustRegister is like Register but panics on error.
+func (r *Registry) MustRegister(goType reflect.Type, metadata *StructMetadata) {
+	if err := r.Register(goType, metadata); err != nil {
+		panic(err)
+	}
 }
 
 // Get retrieves struct metadata from the registry.
 // Returns nil if the type has not been registered.
 func (r *Registry) Get(goType reflect.Type) *StructMetadata {
+	if snapshot := r.sealed.Load(); snapshot != nil {
+		return snapshot.metadata[goType]
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	return r.metadata[goType]
 }
 
+// checkCName reports a C name already registered for a different Go type.
+// Only real C names are checked (see hasCName). The caller must hold r.mu.
+func (r *Registry) checkCName(goType reflect.Type, metadata *StructMetadata) error {
+	if !hasCName(goType, metadata) {
+		return nil
+	}
+	if existing, ok := r.cTypeMap[metadata.CTypeName]; ok && existing != goType {
+		return newRegistrationError(goType,
+			fmt.Sprintf("C name %q is already registered for %s", metadata.CTypeName, qualifiedTypeName(existing)),
+			ErrDuplicateCName)
+	}
+	return nil
+}
+
+// mapCName indexes goType by its C name. A qualified Go name standing in for
+// the C name never replaces another type's entry. The caller must hold r.mu.
+func (r *Registry) mapCName(goType reflect.Type, metadata *StructMetadata) {
+	if _, taken := r.cTypeMap[metadata.CTypeName]; taken && !hasCName(goType, metadata) {
+		return
+	}
+	r.cTypeMap[metadata.CTypeName] = goType
+}
+
+// hasCName reports whether the C name of goType's metadata is a real C name,
+// taken from C metadata or set explicitly, rather than the qualified Go name
+// that types registered without C metadata use. Only real C names must be
+// unique: distinct function-local types can share a qualified Go name.
+func hasCName(goType reflect.Type, metadata *StructMetadata) bool {
+	return metadata.CInfo != nil || metadata.CTypeName != qualifiedTypeName(goType)
+}
+
 // GetByCName retrieves struct metadata by C type name.
 // Returns nil if the type has not been registered.
 func (r *Registry) GetByCName(cTypeName string) *StructMetadata {
+	if snapshot := r.sealed.Load(); snapshot != nil {
+		return snapshot.metadata[snapshot.cTypeMap[cTypeName]]
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -165,6 +343,11 @@ func (r *Registry) GetByCName(cTypeName string) *StructMetadata {
 
 // IsRegistered checks if a type has been precompiled.
 func (r *Registry) IsRegistered(goType reflect.Type) bool {
+	if snapshot := r.sealed.Load(); snapshot != nil {
+		_, ok := snapshot.metadata[goType]
+		return ok
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -180,13 +363,52 @@ func (r *Registry) Count() int {
 	return len(r.metadata)
 }
 
-// Clear removes all registered types (primarily for testing).
+// SetLimits replaces the resource limits of a registered type.
+// The stored metadata is replaced rather than mutated, so concurrent copies
+// keep a consistent view. Returns false if the type is not registered or
+// the registry has been sealed.
+func (r *Registry) SetLimits(goType reflect.Type, limits Limits) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sealed.Load() != nil {
+		return false
+	}
+
+	metadata, ok := r.metadata[goType]
+	if !ok {
+		return false
+	}
+
+	updated := *metadata
+	updated.Limits = limits
+	r.metadata[goType] = &updated
+	return true
+}
+
+// all returns a snapshot of the registered metadata, so callers can inspect
+// it (and look up nested types) without holding the lock.
+func (r *Registry) all() []*StructMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := make([]*StructMetadata, 0, len(r.metadata))
+	for _, metadata := range r.metadata {
+		all = append(all, metadata)
+	}
+	return all
+}
+
+// Clear removes all registered types and unseals the registry (primarily
+// for testing).
 func (r *Registry) Clear() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	r.sealed.Store(nil)
 	r.metadata = make(map[reflect.Type]*StructMetadata)
 	r.cTypeMap = make(map[string]reflect.Type)
+	r.cInfos = make(map[string]CStructInfo)
 }
 
 // globalRegistry is the package-level registry instance.
@@ -195,35 +417,35 @@ var globalRegistry = NewRegistry()
 // CFieldInfo represents metadata for a C struct field extracted from C macros.
 type CFieldInfo struct {
 	// Name is the field name in the C struct.
-	Name string
+	Name string `json:"name"`
 
 	// Type is the C type string (e.g., "int32", "string", "float64").
-	Type string
+	Type string `json:"type"`
 
 	// Offset is the byte offset in the C struct.
-	Offset uintptr
+	Offset uintptr `json:"offset"`
 
 	// Size is the size in bytes.
-	Size uintptr
+	Size uintptr `json:"size"`
 
 	// IsPointer indicates if this is a pointer field.
-	IsPointer bool
+	IsPointer bool `json:"isPointer,omitempty"`
 
 	// IsArray indicates if this is an array field.
-	IsArray bool
+	IsArray bool `json:"isArray,omitempty"`
 
 	// ArrayLen is the array length (0 if not an array).
-	ArrayLen int
+	ArrayLen int `json:"arrayLen,omitempty"`
 }
 
 // CStructInfo represents metadata for a complete C struct extracted from C macros.
 type CStructInfo struct {
 	// Name is the C struct name.
-	Name string
+	Name string `json:"name"`
 
 	// Size is the total size of the C struct.
-	Size uintptr
+	Size uintptr `json:"size"`
 
 	// Fields contains metadata for all fields.
-	Fields []CFieldInfo
+	Fields []CFieldInfo `json:"fields"`
 }
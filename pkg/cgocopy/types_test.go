@@ -1,6 +1,7 @@
 package cgocopy2
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -17,6 +18,9 @@ func TestFieldType_String(t *testing.T) {
 		{"Array", FieldTypeArray, "Array"},
 		{"Slice", FieldTypeSlice, "Slice"},
 		{"Pointer", FieldTypePointer, "Pointer"},
+		{"Opaque", FieldTypeOpaque, "Opaque"},
+		{"Map", FieldTypeMap, "Map"},
+		{"Lazy", FieldTypeLazy, "Lazy"},
 		{"Invalid", FieldTypeInvalid, "Invalid"},
 		{"Unknown", FieldType(999), "Invalid"},
 	}
@@ -72,7 +76,9 @@ func TestRegistry_RegisterAndGet(t *testing.T) {
 	}
 
 	// Register the type
-	r.Register(goType, metadata)
+	if err := r.Register(goType, metadata); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
 
 	// Verify Count
 	if count := r.Count(); count != 1 {
@@ -94,6 +100,26 @@ func TestRegistry_RegisterAndGet(t *testing.T) {
 	}
 }
 
+func TestRegistry_RegisterDuplicateCName(t *testing.T) {
+	r := NewRegistry()
+
+	type First struct{ ID int }
+	type Second struct{ ID int }
+
+	first := &StructMetadata{TypeName: "First", CTypeName: "shared", GoType: reflect.TypeOf(First{})}
+	second := &StructMetadata{TypeName: "Second", CTypeName: "shared", GoType: reflect.TypeOf(Second{})}
+
+	if err := r.Register(first.GoType, first); err != nil {
+		t.Fatalf("Register(First) error = %v", err)
+	}
+	if err := r.Register(second.GoType, second); !errors.Is(err, ErrDuplicateCName) {
+		t.Errorf("Register(Second) error = %v, want ErrDuplicateCName", err)
+	}
+	if r.Get(second.GoType) != nil {
+		t.Error("Second registered despite the error")
+	}
+}
+
 func TestRegistry_GetByCName(t *testing.T) {
 	r := NewRegistry()
 
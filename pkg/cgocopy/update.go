@@ -0,0 +1,199 @@
+package cgocopy2
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+// FieldPath identifies a field by its Go field names, with nested struct
+// fields joined by dots (e.g. "Details.Level").
+type FieldPath string
+
+// Update refreshes an existing Go value from a C struct pointer, assigning
+// only the fields whose C value differs from the current Go value, and
+// returns the paths of the fields it changed (in field order).
+//
+// Nested structs are compared field by field, so a change deep inside a
+// nested struct is reported by its full path. Strings are compared against C
+// memory directly and only allocated when they changed. Lazy fields are
+// rebound only when their C pointer changed. Slices, maps, arrays
+// and pointers are decoded and compared by value.
+//
+// The post-copy hooks of T and its nested structs run after every update,
+// bottom-up as with Copy, whether or not a field changed. Fields assigned by
+// AfterCopyFromC are not reported as changed.
+//
+// On error, dst may be partially updated; the returned paths list the fields
+// assigned before the failure.
+//
+// Example:
+//
+//	var state Device
+//	for range ticker.C {
+//	    changed, err := cgocopy2.Update(&state, unsafe.Pointer(cDevice))
+//	    if err != nil {
+//	        return err
+//	    }
+//	    for _, path := range changed {
+//	        notify(path)
+//	    }
+//	}
+func Update[T any](dst *T, cPtr unsafe.Pointer) ([]FieldPath, error) {
+	return UpdateWith(globalRegistry, dst, cPtr)
+}
+
+// UpdateWith is like Update but resolves T, and every nested type, in reg.
+func UpdateWith[T any](reg *Registry, dst *T, cPtr unsafe.Pointer) ([]FieldPath, error) {
+	goType := reflect.TypeOf((*T)(nil)).Elem()
+
+	if dst == nil || cPtr == nil {
+		return nil, ErrNilPointer
+	}
+
+	metadata := reg.Get(goType)
+	if metadata == nil {
+		return nil, newCopyError(goType, "", "type not registered", ErrNotRegistered)
+	}
+
+	state := newCopyState(reg, metadata.Limits.merge(DefaultLimits()))
+	state.startLogging(metadata)
+
+	region := traceRegion(context.Background(), "Update", metadata.TypeName)
+	defer endTraceRegion(region)
+
+	metrics := loadMetrics()
+	if metrics == nil {
+		return state.update(dst, cPtr, metadata)
+	}
+
+	start := time.Now()
+	changed, err := state.update(dst, cPtr, metadata)
+	recordCopy(metrics, metadata, state, start, err)
+	return changed, err
+}
+
+// update implements UpdateWith once dst and metadata are resolved.
+func (s *copyState) update(dst any, cPtr unsafe.Pointer, metadata *StructMetadata) ([]FieldPath, error) {
+	var changed []FieldPath
+	value := reflect.ValueOf(dst).Elem()
+	if path, err := s.updateStruct(value, cPtr, metadata, "", &changed); err != nil {
+		return changed, newCopyError(metadata.GoType, string(path), "failed to update field", err)
+	}
+	if err := runCopyHooks(value, metadata); err != nil {
+		return changed, newCopyError(metadata.GoType, "", "post-copy hook failed", err)
+	}
+
+	return changed, nil
+}
+
+// updateStruct updates the fields of dst that differ from the C struct at
+// cPtr, appending their paths to changed. On failure it returns the path of
+// the failing field.
+func (s *copyState) updateStruct(dst reflect.Value, cPtr unsafe.Pointer, metadata *StructMetadata,
+	prefix FieldPath, changed *[]FieldPath) (FieldPath, error) {
+	for i := range metadata.Fields {
+		field := &metadata.Fields[i]
+		if field.Skip {
+			continue
+		}
+
+		path := prefix + FieldPath(field.Name)
+		goField := goFieldValue(dst, field)
+		cFieldPtr := unsafe.Pointer(uintptr(cPtr) + field.Offset)
+
+		if field.Type == FieldTypeStruct {
+			nestedMeta := s.nestedMetadata(field, field.ReflectType)
+			if nestedMeta == nil {
+				return path, ErrNotRegistered
+			}
+			if err := s.enter(nestedMeta); err != nil {
+				s.leave()
+				return path, err
+			}
+			failed, err := s.updateStruct(goField, cFieldPtr, nestedMeta, path+".", changed)
+			s.leave()
+			if err != nil {
+				return failed, err
+			}
+			// Nested hooks run before the enclosing struct's hooks
+			if err := runCopyHooks(goField, nestedMeta); err != nil {
+				return path, err
+			}
+			continue
+		}
+
+		updated, err := s.updateField(goField, cFieldPtr, field)
+		if err != nil {
+			return path, err
+		}
+		if s.logging {
+			s.logField("cgocopy: updated field", string(path), field.Offset, field.CType, goField,
+				slog.Bool("changed", updated))
+		}
+		if updated {
+			*changed = append(*changed, path)
+		}
+	}
+
+	return "", nil
+}
+
+// updateField assigns a single non-struct field if its C value differs.
+func (s *copyState) updateField(goField reflect.Value, cPtr unsafe.Pointer, field *FieldInfo) (bool, error) {
+	if field.Type == FieldTypeString {
+		// NULL goes through copyField below to honor required/default
+		if charPtr := *(*unsafe.Pointer)(cPtr); charPtr != nil {
+			if cStringEquals(charPtr, goField.String()) {
+				return false, nil
+			}
+			return true, s.copyString(goField, cPtr)
+		}
+	}
+
+	if field.Type == FieldTypeLazy {
+		// Same C object: keep the memoized value (callers Invalidate to refresh)
+		current := goField.Addr().Interface().(lazyField)
+		if current.Pointer() == *(*unsafe.Pointer)(cPtr) {
+			return false, nil
+		}
+		return true, copyLazy(goField, cPtr, s.registry)
+	}
+
+	fresh := reflect.New(field.ReflectType).Elem()
+	if err := s.copyField(fresh, cPtr, field); err != nil {
+		return false, err
+	}
+
+	switch field.Type {
+	case FieldTypePrimitive, FieldTypeOpaque:
+		if fresh.Equal(goField) {
+			return false, nil
+		}
+	default:
+		if reflect.DeepEqual(fresh.Interface(), goField.Interface()) {
+			return false, nil
+		}
+	}
+
+	goField.Set(fresh)
+	return true, nil
+}
+
+// cStringEquals reports whether the NUL-terminated C string at charPtr equals
+// s, without allocating. A NULL pointer equals the empty string, matching
+// copyString. The walk never goes past len(s)+1 bytes.
+func cStringEquals(charPtr unsafe.Pointer, str string) bool {
+	if charPtr == nil {
+		return str == ""
+	}
+	for i := 0; i < len(str); i++ {
+		b := *(*byte)(unsafe.Pointer(uintptr(charPtr) + uintptr(i)))
+		if b != str[i] {
+			return false
+		}
+	}
+	return *(*byte)(unsafe.Pointer(uintptr(charPtr) + uintptr(len(str)))) == 0
+}
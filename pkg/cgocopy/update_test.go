@@ -0,0 +1,121 @@
+package cgocopy2
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestUpdate(t *testing.T) {
+	Reset()
+	defer Reset()
+	mustPrecompile(t, PrecompileWithC[ProjectionDetails](projectionDetailsInfo))
+	mustPrecompile(t, PrecompileWithC[ProjectionUser](projectionUserInfo))
+
+	c := newCProjectionUser()
+
+	var user ProjectionUser
+	changed, err := Update(&user, unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	want := []FieldPath{"ID", "Email", "Details.FullName", "Details.Level", "Balance"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("first Update() changed = %v, want %v", changed, want)
+	}
+
+	// Nothing changed in C
+	changed, err = Update(&user, unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if len(changed) != 0 {
+		t.Errorf("second Update() changed = %v, want none", changed)
+	}
+
+	// Change one nested field and one string
+	c.Details.Level = 4
+	c.Email = cString("ada@example.org")
+	changed, err = Update(&user, unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	want = []FieldPath{"Email", "Details.Level"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("third Update() changed = %v, want %v", changed, want)
+	}
+	if user.Email != "ada@example.org" || user.Details.Level != 4 {
+		t.Errorf("user = %+v, want updated Email and Details.Level", user)
+	}
+	if user.Details.FullName != "Ada Lovelace" {
+		t.Errorf("Details.FullName = %q, want unchanged", user.Details.FullName)
+	}
+}
+
+func TestUpdate_Slices(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := Precompile[LimitedRecord](); err != nil {
+		t.Fatalf("Precompile() error = %v", err)
+	}
+
+	c := LimitedRecord{Items: []int32{1, 2}}
+	dst := LimitedRecord{Items: []int32{1, 2}}
+
+	changed, err := Update(&dst, unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if len(changed) != 0 {
+		t.Errorf("Update() changed = %v, want none for equal slices", changed)
+	}
+
+	c.Items[1] = 3
+	changed, err = Update(&dst, unsafe.Pointer(&c))
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if !reflect.DeepEqual(changed, []FieldPath{"Items"}) || dst.Items[1] != 3 {
+		t.Errorf("Update() changed = %v, Items = %v, want [Items] and [1 3]", changed, dst.Items)
+	}
+}
+
+func TestCStringEquals(t *testing.T) {
+	tests := []struct {
+		c    *byte
+		s    string
+		want bool
+	}{
+		{nil, "", true},
+		{nil, "x", false},
+		{cString("abc"), "abc", true},
+		{cString("abc"), "ab", false},
+		{cString("ab"), "abc", false},
+		{cString("abd"), "abc", false},
+	}
+
+	for _, tt := range tests {
+		if got := cStringEquals(unsafe.Pointer(tt.c), tt.s); got != tt.want {
+			t.Errorf("cStringEquals(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestUpdate_Errors(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	var user ProjectionUser
+	c := newCProjectionUser()
+
+	if _, err := Update(&user, unsafe.Pointer(&c)); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Update() error = %v, want ErrNotRegistered", err)
+	}
+	if _, err := Update(&user, nil); !errors.Is(err, ErrNilPointer) {
+		t.Errorf("Update(nil) error = %v, want ErrNilPointer", err)
+	}
+}
@@ -1,8 +1,13 @@
 package cgocopy2
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"unsafe"
 )
 
 // ValidateStruct checks if a struct type is properly registered and can be copied.
@@ -11,6 +16,9 @@ import (
 //   - Verifying all fields are supported types
 //   - Ensuring nested structs are also registered
 //   - Validating field metadata completeness
+//   - Cross-checking the C layout of types registered with C metadata
+//
+// All problems found are returned as one joined error (see ValidateAll).
 //
 // This function is useful for debugging registration issues at initialization time.
 //
@@ -23,9 +31,14 @@ import (
 //	    }
 //	}
 func ValidateStruct[T any]() error {
-	var zero T
-	goType := reflect.TypeOf(zero)
+	return globalRegistry.Validate(reflect.TypeFor[T]())
+}
 
+// Validate checks that goType is registered in r and can be copied, with
+// nested types resolved in r. It is the registry-scoped form of
+// ValidateStruct[T], and reports every problem of goType the way
+// ValidateAll does.
+func (r *Registry) Validate(goType reflect.Type) error {
 	// Dereference pointer types
 	if goType.Kind() == reflect.Ptr {
 		goType = goType.Elem()
@@ -37,44 +50,46 @@ func ValidateStruct[T any]() error {
 	}
 
 	// Check if registered
-	metadata := globalRegistry.Get(goType)
+	metadata := r.Get(goType)
 	if metadata == nil {
 		return newValidationError(goType.Name(), "", goType, "",
 			fmt.Sprintf("type not registered - call Precompile[%s]() first", goType.Name()))
 	}
 
-	// Validate metadata completeness
-	if err := validateMetadata(metadata); err != nil {
-		return err
-	}
+	return errors.Join(r.typeErrors(metadata)...)
+}
 
-	// Validate nested structs are also registered
-	if err := validateNestedStructs(metadata); err != nil {
-		return err
+// typeErrors returns every problem found with a registered type, in field
+// order: incomplete metadata, unregistered nested types and an inconsistent
+// C layout. The later checks rely on complete metadata and are skipped
+// without it.
+func (r *Registry) typeErrors(metadata *StructMetadata) []error {
+	if errs := validateMetadata(metadata); len(errs) > 0 {
+		return errs
 	}
-
-	return nil
+	return append(r.validateNestedStructs(metadata), validateLayout(metadata)...)
 }
 
 // validateMetadata checks if struct metadata is complete and valid.
-func validateMetadata(metadata *StructMetadata) error {
+func validateMetadata(metadata *StructMetadata) []error {
 	if metadata.TypeName == "" {
-		return newValidationError("", "", metadata.GoType, "", "metadata missing type name")
+		return []error{newValidationError("", "", metadata.GoType, "", "metadata missing type name")}
 	}
 
 	if metadata.GoType == nil {
-		return newValidationError(metadata.TypeName, "", nil, "", "metadata missing Go type")
+		return []error{newValidationError(metadata.TypeName, "", nil, "", "metadata missing Go type")}
 	}
 
 	// Validate each field
+	var errs []error
 	for i := range metadata.Fields {
 		field := &metadata.Fields[i]
 		if err := validateField(metadata.TypeName, field); err != nil {
-			return err
+			errs = append(errs, err)
 		}
 	}
 
-	return nil
+	return errs
 }
 
 // validateField checks if a single field is valid.
@@ -144,6 +159,37 @@ func validateField(typeName string, field *FieldInfo) error {
 				"pointer field missing element type")
 		}
 
+	case FieldTypeMap:
+		if field.ReflectType.Kind() != reflect.Map {
+			return newValidationError(typeName, field.Name, field.ReflectType, field.CName,
+				fmt.Sprintf("field marked as map but has kind %v", field.ReflectType.Kind()))
+		}
+		if field.KeyType == nil || field.ElemType == nil {
+			return newValidationError(typeName, field.Name, field.ReflectType, field.CName,
+				"map field missing key or value type")
+		}
+		if field.Map == nil {
+			return newValidationError(typeName, field.Name, field.ReflectType, field.CName,
+				"map field missing C layout")
+		}
+
+	case FieldTypeOpaque:
+		if field.ReflectType.Kind() != reflect.UnsafePointer &&
+			field.ReflectType.Kind() != reflect.Uintptr {
+			return newValidationError(typeName, field.Name, field.ReflectType, field.CName,
+				fmt.Sprintf("field marked as opaque but has kind %v", field.ReflectType.Kind()))
+		}
+
+	case FieldTypeLazy:
+		if !isLazyType(field.ReflectType) {
+			return newValidationError(typeName, field.Name, field.ReflectType, field.CName,
+				fmt.Sprintf("field marked as lazy but has type %v", field.ReflectType))
+		}
+		if field.ElemType == nil {
+			return newValidationError(typeName, field.Name, field.ReflectType, field.CName,
+				"lazy field missing element type")
+		}
+
 	default:
 		return newValidationError(typeName, field.Name, field.ReflectType, field.CName,
 			fmt.Sprintf("unsupported field type: %v", field.Type))
@@ -152,8 +198,9 @@ func validateField(typeName string, field *FieldInfo) error {
 	return nil
 }
 
-// validateNestedStructs ensures all nested struct types are registered.
-func validateNestedStructs(metadata *StructMetadata) error {
+// validateNestedStructs ensures all nested struct types are registered in r.
+func (r *Registry) validateNestedStructs(metadata *StructMetadata) []error {
+	var errs []error
 	for i := range metadata.Fields {
 		field := &metadata.Fields[i]
 
@@ -163,59 +210,159 @@ func validateNestedStructs(metadata *StructMetadata) error {
 
 		// Check if field is a nested struct
 		if field.Type == FieldTypeStruct {
-			nestedMeta := globalRegistry.Get(field.ReflectType)
+			nestedMeta := r.Get(field.ReflectType)
 			if nestedMeta == nil {
-				return newValidationError(metadata.TypeName, field.Name, field.ReflectType, field.CName,
+				errs = append(errs, newValidationError(metadata.TypeName, field.Name, field.ReflectType, field.CName,
 					fmt.Sprintf("nested struct type %s not registered - call Precompile[%s]() first",
-						field.ReflectType.Name(), field.ReflectType.Name()))
+						field.ReflectType.Name(), field.ReflectType.Name())))
 			}
 		}
 
 		// Check element type for arrays and slices
 		if field.Type == FieldTypeArray || field.Type == FieldTypeSlice {
 			if field.ElemType.Kind() == reflect.Struct {
-				elemMeta := globalRegistry.Get(field.ElemType)
+				elemMeta := r.Get(field.ElemType)
 				if elemMeta == nil {
-					return newValidationError(metadata.TypeName, field.Name, field.ElemType, field.CName,
+					errs = append(errs, newValidationError(metadata.TypeName, field.Name, field.ElemType, field.CName,
 						fmt.Sprintf("array/slice element type %s not registered - call Precompile[%s]() first",
-							field.ElemType.Name(), field.ElemType.Name()))
+							field.ElemType.Name(), field.ElemType.Name())))
 				}
 			}
 		}
 
-		// Check pointer element type
-		if field.Type == FieldTypePointer {
+		// Check pointer and lazy element types
+		if field.Type == FieldTypePointer || field.Type == FieldTypeLazy {
 			if field.ElemType.Kind() == reflect.Struct {
-				elemMeta := globalRegistry.Get(field.ElemType)
+				elemMeta := r.Get(field.ElemType)
 				if elemMeta == nil {
-					return newValidationError(metadata.TypeName, field.Name, field.ElemType, field.CName,
+					errs = append(errs, newValidationError(metadata.TypeName, field.Name, field.ElemType, field.CName,
 						fmt.Sprintf("pointer element type %s not registered - call Precompile[%s]() first",
-							field.ElemType.Name(), field.ElemType.Name()))
+							field.ElemType.Name(), field.ElemType.Name())))
 				}
 			}
 		}
 	}
 
-	return nil
+	return errs
 }
 
 // ValidateAll validates all registered types.
 // This is useful for checking the entire type registry at once.
-func ValidateAll() []error {
-	globalRegistry.mu.RLock()
-	defer globalRegistry.mu.RUnlock()
+// See Registry.ValidateAll.
+//
+// Example:
+//
+//	if err := cgocopy2.ValidateAll(); err != nil {
+//	    var valErr *cgocopy2.ValidationError
+//	    if errors.As(err, &valErr) {
+//	        log.Printf("first problem: %s.%s", valErr.TypeName, valErr.FieldName)
+//	    }
+//	    log.Fatal(err)
+//	}
+func ValidateAll() error {
+	return globalRegistry.ValidateAll()
+}
+
+// ValidateAll validates all types registered in r and returns every problem
+// found as one error joined with errors.Join, or nil. The problems are
+// *ValidationError values, ordered by type name and then field order, so
+// the result is the same on every run; errors.Is and errors.As see each of
+// them.
+//
+// Besides the checks of Validate, types registered with C metadata have
+// their C layout cross-checked: overlapping C fields, fields extending past
+// the C struct size, arrays whose length times element size differs from
+// the C field size, pointer fields that are not pointer-sized, and *string
+// fields whose C field is not a char*.
+func (r *Registry) ValidateAll() error {
+	all := r.all()
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].TypeName < all[j].TypeName
+	})
+
+	var errs []error
+	for _, metadata := range all {
+		errs = append(errs, r.typeErrors(metadata)...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateLayout cross-checks the C layout of a type registered with C
+// metadata. Without C metadata the C layout is the Go layout, which is
+// consistent by construction.
+func validateLayout(metadata *StructMetadata) []error {
+	cInfo := metadata.CInfo
+	if cInfo == nil {
+		return nil
+	}
+
+	var errs []error
+	ptrSize := unsafe.Sizeof(uintptr(0))
+
+	// C fields in memory order; each must start after the furthest end of
+	// the fields before it and end within the struct
+	cFields := slices.Clone(cInfo.Fields)
+	sort.SliceStable(cFields, func(i, j int) bool {
+		return cFields[i].Offset < cFields[j].Offset
+	})
+	var prev *CFieldInfo
+	for i := range cFields {
+		cField := &cFields[i]
+		if cField.Offset+cField.Size > cInfo.Size {
+			errs = append(errs, newValidationError(metadata.TypeName, "", metadata.GoType, cField.Type,
+				fmt.Sprintf("C field %s (offset %d, size %d) extends past the C struct size %d",
+					cField.Name, cField.Offset, cField.Size, cInfo.Size)))
+		}
+		if prev != nil && cField.Offset < prev.Offset+prev.Size {
+			errs = append(errs, newValidationError(metadata.TypeName, "", metadata.GoType, cField.Type,
+				fmt.Sprintf("C field %s (offset %d) overlaps C field %s (offset %d, size %d)",
+					cField.Name, cField.Offset, prev.Name, prev.Offset, prev.Size)))
+		}
+		if cField.Size > 0 && (prev == nil || cField.Offset+cField.Size > prev.Offset+prev.Size) {
+			prev = cField
+		}
+		if isCPointerField(cField) && cField.Size != ptrSize {
+			errs = append(errs, newValidationError(metadata.TypeName, "", metadata.GoType, cField.Type,
+				fmt.Sprintf("C pointer field %s is %d bytes, want pointer size %d",
+					cField.Name, cField.Size, ptrSize)))
+		}
+	}
 
-	var errors []error
-	for _, metadata := range globalRegistry.metadata {
-		if err := validateMetadata(metadata); err != nil {
-			errors = append(errors, err)
+	// Go fields read as pointers or arrays must match the C field sizes
+	for i := range metadata.Fields {
+		field := &metadata.Fields[i]
+		if field.Skip {
+			continue
 		}
-		if err := validateNestedStructs(metadata); err != nil {
-			errors = append(errors, err)
+
+		switch field.Type {
+		case FieldTypeString, FieldTypePointer, FieldTypeOpaque, FieldTypeLazy:
+			// An inline char array can be pointer-sized (char[8])
+			if strings.HasSuffix(strings.TrimSpace(field.CType), "[]") {
+				errs = append(errs, newValidationError(metadata.TypeName, field.Name, field.ReflectType, field.CType,
+					fmt.Sprintf("C field %s is an inline array of type %q, not a pointer; use a Go array", field.CName, field.CType)))
+				continue
+			}
+			if field.Size != ptrSize {
+				errs = append(errs, newValidationError(metadata.TypeName, field.Name, field.ReflectType, field.CType,
+					fmt.Sprintf("C field %s is %d bytes, want pointer size %d", field.CName, field.Size, ptrSize)))
+			}
+			// A *string reads the C field itself as the char*, not a char**
+			if field.Type == FieldTypePointer && field.ElemType.Kind() == reflect.String && !isCStringType(field.CType) {
+				errs = append(errs, newValidationError(metadata.TypeName, field.Name, field.ReflectType, field.CType,
+					fmt.Sprintf("*string field needs a char* C field, C field %s has type %q", field.CName, field.CType)))
+			}
+		case FieldTypeArray:
+			elemSize := field.ElemType.Size()
+			if want := uintptr(field.ArrayLen) * elemSize; want != field.Size {
+				errs = append(errs, newValidationError(metadata.TypeName, field.Name, field.ReflectType, field.CType,
+					fmt.Sprintf("array of %d × %d-byte elements is %d bytes, C field %s is %d bytes",
+						field.ArrayLen, elemSize, want, field.CName, field.Size)))
+			}
 		}
 	}
 
-	return errors
+	return errs
 }
 
 // MustValidateStruct is like ValidateStruct but panics on error.
@@ -226,15 +373,21 @@ func MustValidateStruct[T any]() {
 	}
 }
 
-// GetRegisteredTypes returns a list of all registered type names.
+// GetRegisteredTypes returns the package-qualified names of all registered
+// types, sorted.
 // This is useful for debugging and introspection.
 func GetRegisteredTypes() []string {
-	globalRegistry.mu.RLock()
-	defer globalRegistry.mu.RUnlock()
+	return globalRegistry.RegisteredTypes()
+}
 
-	types := make([]string, 0, len(globalRegistry.metadata))
-	for _, metadata := range globalRegistry.metadata {
+// RegisteredTypes returns the package-qualified names of all types
+// registered in r, sorted.
+func (r *Registry) RegisteredTypes() []string {
+	all := r.all()
+	types := make([]string, 0, len(all))
+	for _, metadata := range all {
 		types = append(types, metadata.TypeName)
 	}
+	sort.Strings(types)
 	return types
 }
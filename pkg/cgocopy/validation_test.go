@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"unsafe"
 )
 
 func TestValidateStruct_Valid(t *testing.T) {
@@ -98,9 +99,7 @@ func TestValidateStruct_NestedStructNotRegistered(t *testing.T) {
 	}
 
 	// Only register Outer, not Inner
-	if err := Precompile[Outer](); err != nil {
-		t.Fatalf("Precompile[Outer]() error = %v", err)
-	}
+	registerWithoutDependencies[Outer](t)
 
 	err := ValidateStruct[Outer]()
 	if err == nil {
@@ -235,9 +234,7 @@ func TestValidateStruct_ArrayOfStructsNotRegistered(t *testing.T) {
 	}
 
 	// Only register Container, not Element
-	if err := Precompile[Container](); err != nil {
-		t.Fatalf("Precompile[Container]() error = %v", err)
-	}
+	registerWithoutDependencies[Container](t)
 
 	err := ValidateStruct[Container]()
 	if err == nil {
@@ -265,9 +262,8 @@ func TestValidateAll(t *testing.T) {
 	Precompile[Type1]()
 	Precompile[Type2]()
 
-	errors := ValidateAll()
-	if len(errors) != 0 {
-		t.Errorf("ValidateAll() returned %d errors, want 0: %v", len(errors), errors)
+	if err := ValidateAll(); err != nil {
+		t.Errorf("ValidateAll() error = %v, want nil", err)
 	}
 }
 
@@ -284,11 +280,185 @@ func TestValidateAll_WithErrors(t *testing.T) {
 	}
 
 	// Register Outer but not Inner (this creates an invalid state)
-	Precompile[Outer]()
+	registerWithoutDependencies[Outer](t)
 
-	errors := ValidateAll()
-	if len(errors) == 0 {
-		t.Error("ValidateAll() returned no errors, want at least 1 for unregistered nested struct")
+	err := ValidateAll()
+	if err == nil {
+		t.Fatal("ValidateAll() error = nil, want error for unregistered nested struct")
+	}
+
+	var valErr *ValidationError
+	if !errors.As(err, &valErr) || valErr.FieldName != "Data" {
+		t.Errorf("ValidateAll() error = %v, want ValidationError for field Data", err)
+	}
+	if !errors.Is(err, &ValidationError{}) {
+		t.Errorf("errors.Is(%v, ValidationError) = false", err)
+	}
+}
+
+func TestValidateAll_Deterministic(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Leaf struct {
+		Value int
+	}
+	type ZOuter struct {
+		A Leaf
+		B Leaf
+	}
+	type AOuter struct {
+		Data Leaf
+	}
+
+	registerWithoutDependencies[ZOuter](t)
+	registerWithoutDependencies[AOuter](t)
+
+	err := ValidateAll()
+	if err == nil {
+		t.Fatal("ValidateAll() error = nil, want unregistered nested structs")
+	}
+	for range 10 {
+		if again := ValidateAll(); again.Error() != err.Error() {
+			t.Fatalf("ValidateAll() not deterministic:\n%v\nthen\n%v", err, again)
+		}
+	}
+
+	// Every problem is reported, ordered by type name and then field
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("ValidateAll() error %T is not a joined error", err)
+	}
+	var got []string
+	for _, e := range joined.Unwrap() {
+		var valErr *ValidationError
+		if !errors.As(e, &valErr) {
+			t.Fatalf("error %v is not a ValidationError", e)
+		}
+		got = append(got, valErr.TypeName[strings.LastIndex(valErr.TypeName, ".")+1:]+"."+valErr.FieldName)
+	}
+	want := []string{"AOuter.Data", "ZOuter.A", "ZOuter.B"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateAll() problems = %v, want %v", got, want)
+	}
+}
+
+type LayoutBroken struct {
+	ID     int32
+	Values [4]int16
+	Name   string
+	Tail   uint32
+}
+
+func TestValidateAll_LayoutChecks(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	err := PrecompileWithC[LayoutBroken](CStructInfo{
+		Name: "layout_broken",
+		Size: 16,
+		Fields: []CFieldInfo{
+			{Name: "id", Type: "int32", Offset: 0, Size: 4},
+			{Name: "values", Type: "int16[]", Offset: 2, Size: 6, IsArray: true, ArrayLen: 4},
+			{Name: "name", Type: "string", Offset: 8, Size: 4, IsPointer: true},
+			{Name: "tail", Type: "uint32", Offset: 12, Size: 8},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+
+	err = ValidateAll()
+	if err == nil {
+		t.Fatal("ValidateAll() error = nil, want layout problems")
+	}
+	for _, want := range []string{
+		"C field values (offset 2) overlaps C field id (offset 0, size 4)",
+		"C pointer field name is 4 bytes, want pointer size 8",
+		"C field tail (offset 12, size 8) extends past the C struct size 16",
+		"array of 4 × 2-byte elements is 8 bytes, C field values is 6 bytes",
+		"C field name is 4 bytes, want pointer size 8",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidateAll() error missing %q:\n%v", want, err)
+		}
+	}
+
+	var valErr *ValidationError
+	if !errors.As(err, &valErr) || !strings.HasSuffix(valErr.TypeName, ".LayoutBroken") {
+		t.Errorf("errors.As() = %v, want ValidationError for LayoutBroken", valErr)
+	}
+
+	// Validate reports the same problems for the single type
+	if err := ValidateStruct[LayoutBroken](); err == nil || !strings.Contains(err.Error(), "overlaps") {
+		t.Errorf("ValidateStruct() error = %v, want layout problems", err)
+	}
+}
+
+func TestValidate_StringPointerNeedsCharPointer(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	type Labels struct {
+		Name  *string `cgocopy:"name"`
+		Alias *string `cgocopy:"alias"`
+	}
+
+	ptrSize := unsafe.Sizeof(uintptr(0))
+	err := PrecompileWithC[Labels](CStructInfo{
+		Name: "labels",
+		Size: 2 * ptrSize,
+		Fields: []CFieldInfo{
+			{Name: "name", Type: "string", Offset: 0, Size: ptrSize, IsPointer: true},
+			// char** alias, declared with CGOCOPY_POINTER_FIELD
+			{Name: "alias", Type: "pointer", Offset: ptrSize, Size: ptrSize, IsPointer: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+
+	err = ValidateStruct[Labels]()
+	if err == nil || !strings.Contains(err.Error(), `C field alias has type "pointer"`) {
+		t.Fatalf("ValidateStruct() error = %v, want char* error for alias", err)
+	}
+	if strings.Contains(err.Error(), "C field name has type") {
+		t.Errorf("ValidateStruct() error = %v, char* field name reported", err)
+	}
+}
+
+func TestValidate_StringNeedsPointerNotCharArray(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	// char code[8] is as large as a char*, so only the array flag tells them apart
+	type Coded struct {
+		Code string `cgocopy:"code"`
+	}
+	err := PrecompileWithC[Coded](CStructInfo{
+		Name:   "coded",
+		Size:   8,
+		Fields: []CFieldInfo{{Name: "code", Type: "char[]", Size: 8, IsArray: true, ArrayLen: 8}},
+	})
+	if err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+
+	err = ValidateStruct[Coded]()
+	if err == nil || !strings.Contains(err.Error(), "C field code is an inline array") {
+		t.Fatalf("ValidateStruct() error = %v, want inline array error", err)
+	}
+}
+
+func TestValidateAll_ConsistentCLayout(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if err := PrecompileWithC[LayoutDevice](layoutDeviceInfo); err != nil {
+		t.Fatalf("PrecompileWithC() error = %v", err)
+	}
+	if err := ValidateAll(); err != nil {
+		t.Errorf("ValidateAll() error = %v, want nil", err)
 	}
 }
 
@@ -352,19 +522,11 @@ func TestGetRegisteredTypes(t *testing.T) {
 		t.Errorf("GetRegisteredTypes() length = %d, want 2", len(types))
 	}
 
-	// Check both types are present
-	found1, found2 := false, false
-	for _, name := range types {
-		if name == "Type1" {
-			found1 = true
-		}
-		if name == "Type2" {
-			found2 = true
-		}
-	}
-
-	if !found1 || !found2 {
-		t.Errorf("GetRegisteredTypes() = %v, missing Type1 or Type2", types)
+	// Names are package-qualified and sorted
+	pkg := reflect.TypeFor[Type1]().PkgPath()
+	want := []string{pkg + ".Type1", pkg + ".Type2"}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("GetRegisteredTypes() = %v, want %v", types, want)
 	}
 }
 
@@ -416,6 +578,22 @@ func TestValidateField_MissingReflectType(t *testing.T) {
 }
 
 // Helper functions for tests
+
+// registerWithoutDependencies registers T's own metadata in the global
+// registry, bypassing the automatic registration of nested types that
+// Precompile performs, to set up an invalid registry state.
+func registerWithoutDependencies[T any](t *testing.T) {
+	t.Helper()
+
+	metadata, err := analyzeStruct(reflect.TypeFor[T]())
+	if err != nil {
+		t.Fatalf("analyzeStruct() error = %v", err)
+	}
+	if err := globalRegistry.Register(metadata.GoType, metadata); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+}
+
 func stringType() reflect.Type {
 	return reflect.TypeOf("")
 }
@@ -3,6 +3,7 @@ package cgocopy2
 import (
 	"encoding/json"
 	"testing"
+	"unsafe"
 )
 
 // JSON-friendly versions (for fair comparison)
@@ -33,6 +34,7 @@ type GameObjectJSON struct {
 // ============================================================================
 
 func BenchmarkCgocopy_SimplePerson(b *testing.B) {
+	precompileBenchTypes(b)
 	cPerson := CreateBenchPerson(42, "John Doe", 1234.56, 1)
 	defer FreeCPointer(cPerson)
 
@@ -65,6 +67,7 @@ func BenchmarkJSON_SimplePerson(b *testing.B) {
 // ============================================================================
 
 func BenchmarkCgocopy_GameObject(b *testing.B) {
+	precompileBenchTypes(b)
 	cObj := CreateGameObject(123, "Player", 100.0, 50)
 	defer FreeCPointer(cObj)
 
@@ -114,3 +117,52 @@ func BenchmarkJSON_GameObject(b *testing.B) {
 //   - JSON:     2606ns  (9 allocs)
 //
 // Conclusion: cgocopy is 20-50x faster than JSON for real-world structs!
+
+// precompileBenchTypes registers the C benchmark types in a fresh global
+// registry (other tests Reset it, so registering in init is not enough).
+func precompileBenchTypes(b *testing.B) {
+	b.Helper()
+	Reset()
+	b.Cleanup(Reset)
+
+	mustPrecompileBench(b, PrecompileWithC[BenchPerson](CStructInfo{
+		Name: "BenchPerson",
+		Size: unsafe.Sizeof(BenchPerson{}),
+		Fields: []CFieldInfo{
+			{Name: "id", Type: "int32", Offset: 0, Size: 4},
+			{Name: "name", Type: "int8", Offset: 4, Size: 1, IsArray: true, ArrayLen: 64},
+			{Name: "balance", Type: "float64", Offset: 72, Size: 8},
+			{Name: "active", Type: "int32", Offset: 80, Size: 4},
+		},
+	}))
+
+	mustPrecompileBench(b, PrecompileWithC[Point3DBench](CStructInfo{
+		Name: "Point3D",
+		Size: unsafe.Sizeof(Point3DBench{}),
+		Fields: []CFieldInfo{
+			{Name: "x", Type: "float64", Offset: 0, Size: 8},
+			{Name: "y", Type: "float64", Offset: 8, Size: 8},
+			{Name: "z", Type: "float64", Offset: 16, Size: 8},
+		},
+	}))
+
+	mustPrecompileBench(b, PrecompileWithC[GameObjectBench](CStructInfo{
+		Name: "GameObject",
+		Size: unsafe.Sizeof(GameObjectBench{}),
+		Fields: []CFieldInfo{
+			{Name: "id", Type: "int32", Offset: 0, Size: 4},
+			{Name: "name", Type: "int8", Offset: 4, Size: 1, IsArray: true, ArrayLen: 64},
+			{Name: "position", Type: "struct", Offset: 72, Size: 24},
+			{Name: "velocity", Type: "struct", Offset: 96, Size: 24},
+			{Name: "health", Type: "float32", Offset: 120, Size: 4},
+			{Name: "level", Type: "int32", Offset: 124, Size: 4},
+		},
+	}))
+}
+
+func mustPrecompileBench(b *testing.B, err error) {
+	b.Helper()
+	if err != nil {
+		b.Fatalf("PrecompileWithC() error = %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+// Package cparse extracts struct definitions from C headers. It is the
+// parser behind cgocopy-generate, shared with tools that check Go types
+// against the same headers.
+package cparse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Field represents a C struct field
+type Field struct {
+	Name      string
+	Type      string
+	ArraySize string // empty if not array
+	// NestedType is the C struct type of a field that embeds a struct by
+	// value, without the "struct" prefix; empty for every other field.
+	NestedType string
+}
+
+// IsOpaquePointer reports whether the field is a pointer other than a C string.
+// Such fields are emitted with CGOCOPY_POINTER_FIELD so Go can validate them.
+func (f Field) IsOpaquePointer() bool {
+	if f.ArraySize != "" || !strings.Contains(f.Type, "*") {
+		return false
+	}
+	base := strings.Join(strings.Fields(strings.ReplaceAll(f.Type, "*", " * ")), " ")
+	base = strings.TrimPrefix(base, "const ")
+	return base != "char *"
+}
+
+// Struct represents a C struct definition
+type Struct struct {
+	Name   string
+	Fields []Field
+}
+
+// ParseStructs extracts struct definitions from C code
+func ParseStructs(content string) ([]Struct, error) {
+	var structs []Struct
+	structNames := make(map[string]bool)
+
+	// Remove comments first
+	content = RemoveComments(content)
+
+	// Match struct definitions:
+	// - typedef struct { ... } Name;
+	// - struct Name { ... };
+	// - typedef struct Name { ... } Name;
+	structRegex := regexp.MustCompile(`(?:typedef\s+)?struct(?:\s+(\w+))?\s*\{([^}]+)\}(?:\s*(\w+))?`)
+	matches := structRegex.FindAllStringSubmatch(content, -1)
+
+	for _, match := range matches {
+		// Extract struct name (either before or after body)
+		name := match[1]
+		if name == "" {
+			name = match[3]
+		}
+		for _, n := range []string{match[1], match[3]} {
+			if n != "" {
+				structNames[n] = true
+			}
+		}
+		if name == "" {
+			continue // Anonymous struct, skip
+		}
+
+		body := match[2]
+		s := Struct{Name: name}
+
+		// Parse fields: type name; or type name[size];
+		// Handles: int x; char* name; double arr[10]; Point3D position;
+		fieldRegex := regexp.MustCompile(`([a-zA-Z_][\w\s\*]+?)\s+(\w+)(?:\[(\d+)\])?\s*;`)
+		fieldMatches := fieldRegex.FindAllStringSubmatch(body, -1)
+
+		for _, fm := range fieldMatches {
+			fieldType := strings.TrimSpace(fm[1])
+			fieldName := fm[2]
+			arraySize := fm[3]
+
+			s.Fields = append(s.Fields, Field{
+				Name:      fieldName,
+				Type:      fieldType,
+				ArraySize: arraySize,
+			})
+		}
+
+		if len(s.Fields) > 0 {
+			structs = append(structs, s)
+		}
+	}
+
+	for i := range structs {
+		for j := range structs[i].Fields {
+			structs[i].Fields[j].NestedType = nestedType(structs[i].Fields[j], structNames)
+		}
+	}
+
+	return structs, nil
+}
+
+// nestedType returns the struct type a field embeds by value: a type
+// declared with the struct keyword, or the name of a struct in the same
+// input.
+func nestedType(f Field, structNames map[string]bool) string {
+	if f.ArraySize != "" || strings.Contains(f.Type, "*") {
+		return ""
+	}
+	name := strings.Join(strings.Fields(strings.TrimPrefix(f.Type, "const ")), " ")
+	if trimmed, ok := strings.CutPrefix(name, "struct "); ok {
+		return trimmed
+	}
+	if structNames[name] {
+		return name
+	}
+	return ""
+}
+
+// RemoveComments removes C/C++ style comments from source code
+func RemoveComments(content string) string {
+	// Remove // comments (line comments)
+	lineComment := regexp.MustCompile(`//.*`)
+	content = lineComment.ReplaceAllString(content, "")
+
+	// Remove /* */ comments (block comments)
+	blockComment := regexp.MustCompile(`(?s)/\*.*?\*/`)
+	content = blockComment.ReplaceAllString(content, "")
+
+	return content
+}
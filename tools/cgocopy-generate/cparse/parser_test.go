@@ -1,4 +1,4 @@
-package main
+package cparse
 
 import (
 	"strings"
@@ -13,9 +13,9 @@ typedef struct {
 } SimplePerson;
 `
 
-	structs, err := parseStructs(input)
+	structs, err := ParseStructs(input)
 	if err != nil {
-		t.Fatalf("parseStructs failed: %v", err)
+		t.Fatalf("ParseStructs failed: %v", err)
 	}
 
 	if len(structs) != 1 {
@@ -49,9 +49,9 @@ typedef struct {
 } User;
 `
 
-	structs, err := parseStructs(input)
+	structs, err := ParseStructs(input)
 	if err != nil {
-		t.Fatalf("parseStructs failed: %v", err)
+		t.Fatalf("ParseStructs failed: %v", err)
 	}
 
 	if len(structs) != 1 {
@@ -78,9 +78,9 @@ struct Student {
 };
 `
 
-	structs, err := parseStructs(input)
+	structs, err := ParseStructs(input)
 	if err != nil {
-		t.Fatalf("parseStructs failed: %v", err)
+		t.Fatalf("ParseStructs failed: %v", err)
 	}
 
 	if len(structs) != 1 {
@@ -126,9 +126,9 @@ typedef struct {
 } GameObject;
 `
 
-	structs, err := parseStructs(input)
+	structs, err := ParseStructs(input)
 	if err != nil {
-		t.Fatalf("parseStructs failed: %v", err)
+		t.Fatalf("ParseStructs failed: %v", err)
 	}
 
 	if len(structs) != 2 {
@@ -154,6 +154,50 @@ typedef struct {
 	if gameObject.Fields[1].Type != "Point3D" {
 		t.Errorf("expected type 'Point3D', got '%s'", gameObject.Fields[1].Type)
 	}
+	if gameObject.Fields[1].NestedType != "Point3D" {
+		t.Errorf("expected nested type 'Point3D', got '%s'", gameObject.Fields[1].NestedType)
+	}
+}
+
+func TestParseStructs_NestedType(t *testing.T) {
+	input := `
+struct Vec {
+    float x;
+    float y;
+};
+
+typedef struct {
+    struct Vec origin;
+    struct Extent size;
+    Vec* next;
+    Vec corners[4];
+    char* label;
+    int id;
+} Box;
+`
+
+	structs, err := ParseStructs(input)
+	if err != nil {
+		t.Fatalf("ParseStructs failed: %v", err)
+	}
+
+	if len(structs) != 2 {
+		t.Fatalf("expected 2 structs, got %d", len(structs))
+	}
+
+	want := map[string]string{
+		"origin":  "Vec",
+		"size":    "Extent", // declared elsewhere, but named with the struct keyword
+		"next":    "",
+		"corners": "",
+		"label":   "",
+		"id":      "",
+	}
+	for _, f := range structs[1].Fields {
+		if f.NestedType != want[f.Name] {
+			t.Errorf("field %s: expected nested type '%s', got '%s'", f.Name, want[f.Name], f.NestedType)
+		}
+	}
 }
 
 func TestParseStructs_MultipleStructs(t *testing.T) {
@@ -168,9 +212,9 @@ typedef struct {
 } Point;
 `
 
-	structs, err := parseStructs(input)
+	structs, err := ParseStructs(input)
 	if err != nil {
-		t.Fatalf("parseStructs failed: %v", err)
+		t.Fatalf("ParseStructs failed: %v", err)
 	}
 
 	if len(structs) != 2 {
@@ -194,7 +238,7 @@ struct Test { // inline comment
 };
 `
 
-	result := removeComments(input)
+	result := RemoveComments(input)
 
 	if strings.Contains(result, "//") {
 		t.Error("line comments should be removed")
@@ -216,7 +260,7 @@ struct Test {
    here */
 `
 
-	result := removeComments(input)
+	result := RemoveComments(input)
 
 	if strings.Contains(result, "/*") || strings.Contains(result, "*/") {
 		t.Error("block comments should be removed")
@@ -238,9 +282,9 @@ typedef struct {
 } User;
 `
 
-	structs, err := parseStructs(input)
+	structs, err := ParseStructs(input)
 	if err != nil {
-		t.Fatalf("parseStructs failed: %v", err)
+		t.Fatalf("ParseStructs failed: %v", err)
 	}
 
 	if len(structs) != 1 {
@@ -256,9 +300,9 @@ typedef struct {
 func TestParseStructs_EmptyInput(t *testing.T) {
 	input := ``
 
-	structs, err := parseStructs(input)
+	structs, err := ParseStructs(input)
 	if err != nil {
-		t.Fatalf("parseStructs failed: %v", err)
+		t.Fatalf("ParseStructs failed: %v", err)
 	}
 
 	if len(structs) != 0 {
@@ -272,9 +316,9 @@ func TestParseStructs_NoStructs(t *testing.T) {
 int x = 5;
 `
 
-	structs, err := parseStructs(input)
+	structs, err := ParseStructs(input)
 	if err != nil {
-		t.Fatalf("parseStructs failed: %v", err)
+		t.Fatalf("ParseStructs failed: %v", err)
 	}
 
 	if len(structs) != 0 {
@@ -299,9 +343,9 @@ typedef struct {
 } AllTypes;
 `
 
-	structs, err := parseStructs(input)
+	structs, err := ParseStructs(input)
 	if err != nil {
-		t.Fatalf("parseStructs failed: %v", err)
+		t.Fatalf("ParseStructs failed: %v", err)
 	}
 
 	if len(structs) != 1 {
@@ -317,3 +361,24 @@ typedef struct {
 		t.Errorf("expected 11 fields, got %d", len(s.Fields))
 	}
 }
+
+func TestField_IsOpaquePointer(t *testing.T) {
+	tests := []struct {
+		field Field
+		want  bool
+	}{
+		{Field{Name: "name", Type: "char*"}, false},
+		{Field{Name: "label", Type: "const char*"}, false},
+		{Field{Name: "id", Type: "int"}, false},
+		{Field{Name: "user_data", Type: "void*"}, true},
+		{Field{Name: "next", Type: "Node*"}, true},
+		{Field{Name: "argv", Type: "char**"}, true},
+		{Field{Name: "buf", Type: "char*", ArraySize: "4"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.field.IsOpaquePointer(); got != tt.want {
+			t.Errorf("IsOpaquePointer(%q) = %v, want %v", tt.field.Type, got, tt.want)
+		}
+	}
+}
@@ -3,12 +3,14 @@ package main
 import (
 	"os"
 	"text/template"
+
+	"github.com/shaban/cgocopy/tools/cgocopy-generate/cparse"
 )
 
 // TemplateData contains data for template rendering
 type TemplateData struct {
 	InputFile  string
-	Structs    []Struct
+	Structs    []cparse.Struct
 	MacrosPath string
 }
 
@@ -24,7 +26,7 @@ const metadataTemplate = `// GENERATED CODE - DO NOT EDIT
 // Metadata for {{$struct.Name}}
 CGOCOPY_STRUCT({{$struct.Name}},
 {{- range $idx, $field := $struct.Fields}}
-    CGOCOPY_FIELD({{$struct.Name}}, {{$field.Name}}){{if ne $idx (sub1 (len $struct.Fields))}},{{end}}
+    {{if $field.NestedType}}CGOCOPY_STRUCT_FIELD({{$struct.Name}}, {{$field.Name}}, {{$field.NestedType}}){{else if $field.IsOpaquePointer}}CGOCOPY_POINTER_FIELD({{$struct.Name}}, {{$field.Name}}){{else}}CGOCOPY_FIELD({{$struct.Name}}, {{$field.Name}}){{end}}{{if ne $idx (sub1 (len $struct.Fields))}},{{end}}
 {{- end}}
 )
 
@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/shaban/cgocopy/tools/cgocopy-generate/cparse"
 )
 
 func main() {
@@ -38,7 +40,7 @@ func main() {
 	}
 
 	// Parse structs
-	structs, err := parseStructs(string(content))
+	structs, err := cparse.ParseStructs(string(content))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing structs: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,367 @@
+// Package cgocopycheck defines an Analyzer that reports misuse of the
+// cgocopy package that can be found without running the program.
+package cgocopycheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const doc = `check usage of the cgocopy package
+
+The cgocopy analyzer reports:
+
+  - Copy[T] and the other copy functions called with a type T that is never
+    registered with Precompile, PrecompileWithC or PrecompileAuto, neither
+    directly nor as a nested type of a registered type;
+  - malformed cgocopy struct tags: invalid C names, unknown options and
+    options that do not apply to the field;
+  - fields of a kind cgocopy cannot copy (chan, func, interface, complex)
+    that are not skipped with cgocopy:"-";
+  - with -headers, tags naming a C field that the C struct does not have.
+
+Registrations are seen in the analyzed package and the packages it
+imports. If the type of some registration cannot be determined statically,
+the unregistered-type check is skipped.`
+
+// cgocopyPath is the import path of the cgocopy package.
+const cgocopyPath = "github.com/shaban/cgocopy/pkg/cgocopy"
+
+// Analyzer reports misuse of the cgocopy package.
+var Analyzer = &analysis.Analyzer{
+	Name:      "cgocopy",
+	Doc:       doc,
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(registrations)},
+}
+
+// headers is the value of the -headers flag.
+var headers string
+
+func init() {
+	Analyzer.Flags.StringVar(&headers, "headers", "",
+		"comma-separated C headers to check cgocopy tags against; relative paths are resolved against the package directory")
+}
+
+// registrations is the package fact listing the types a package registers,
+// including the nested types registered along with them.
+type registrations struct {
+	// Types are the package-qualified type names, sorted.
+	Types []string
+
+	// Dynamic is true if the type of some registration in the package could
+	// not be determined.
+	Dynamic bool
+}
+
+func (*registrations) AFact() {}
+
+func (r *registrations) String() string {
+	parts := r.Types
+	if r.Dynamic {
+		parts = append(parts[:len(parts):len(parts)], "dynamic")
+	}
+	return "registrations(" + strings.Join(parts, ", ") + ")"
+}
+
+// registerFuncs are the generic functions that register their type argument.
+var registerFuncs = map[string]bool{
+	"Precompile":      true,
+	"PrecompileWithC": true,
+	"PrecompileAuto":  true,
+}
+
+// registerMethods are the Registry methods that register the reflect.Type
+// passed as their first argument.
+var registerMethods = map[string]bool{
+	"Precompile":      true,
+	"PrecompileWithC": true,
+	"PrecompileAuto":  true,
+}
+
+// copyFuncs are the generic functions whose type argument must be registered.
+var copyFuncs = map[string]bool{
+	"Copy":                  true,
+	"CopyWith":              true,
+	"CopyWithLimits":        true,
+	"CopyWithLimitsWith":    true,
+	"CopyFields":            true,
+	"CopyFieldsWith":        true,
+	"CopyArrayParallel":     true,
+	"CopyArrayParallelWith": true,
+	"Each":                  true,
+	"EachWith":              true,
+	"EachReuse":             true,
+	"EachReuseWith":         true,
+	"EachBuffer":            true,
+	"EachBufferWith":        true,
+	"Update":                true,
+	"UpdateWith":            true,
+	"NewProjection":         true,
+	"NewProjectionWith":     true,
+	"LayoutReport":          true,
+}
+
+// copyCall is a call of one of copyFuncs.
+type copyCall struct {
+	call  *ast.CallExpr
+	name  string
+	named *types.Named
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	// The package itself and its tests misuse it on purpose
+	if strings.TrimSuffix(pass.Pkg.Path(), "_test") == cgocopyPath {
+		return nil, nil
+	}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	registered := make(map[string]bool)
+	var dynamic bool
+	var copies []copyCall
+
+	// Struct types to check: registered and copied types of this package,
+	// with their nested types, and any struct carrying a cgocopy tag
+	checked := make(map[*types.Named]bool)
+	addChecked := func(named *types.Named) {
+		walkNested(named, func(nested *types.Named) bool {
+			if checked[nested] {
+				return false
+			}
+			checked[nested] = true
+			return true
+		})
+	}
+	register := func(t types.Type) {
+		// Registrations in generic code register types only known at
+		// instantiation
+		named := structType(t)
+		if named == nil {
+			dynamic = true
+			return
+		}
+		walkNested(named, func(nested *types.Named) bool {
+			key := typeKey(nested)
+			if registered[key] {
+				return false
+			}
+			registered[key] = true
+			return true
+		})
+		addChecked(named)
+	}
+
+	nodeFilter := []ast.Node{(*ast.CallExpr)(nil), (*ast.TypeSpec)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		if spec, ok := n.(*ast.TypeSpec); ok {
+			if named, ok := pass.TypesInfo.Defs[spec.Name].Type().(*types.Named); ok && hasCgocopyTag(named) {
+				addChecked(named)
+			}
+			return
+		}
+
+		call := n.(*ast.CallExpr)
+		fn, _ := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != cgocopyPath {
+			return
+		}
+
+		if fn.Signature().Recv() != nil {
+			if recvName(fn) != "Registry" {
+				return
+			}
+			switch {
+			case registerMethods[fn.Name()] && len(call.Args) > 0:
+				register(reflectedType(pass.TypesInfo, call.Args[0]))
+			case fn.Name() == "Import":
+				registerImported(pass.TypesInfo, call, &dynamic, register)
+			}
+			return
+		}
+
+		switch {
+		case registerFuncs[fn.Name()]:
+			register(typeArg(pass.TypesInfo, call))
+		case copyFuncs[fn.Name()]:
+			t := typeArg(pass.TypesInfo, call)
+			if named := structType(t); named != nil {
+				copies = append(copies, copyCall{call: call, name: fn.Name(), named: named})
+				addChecked(named)
+			}
+		case fn.Name() == "Import":
+			registerImported(pass.TypesInfo, call, &dynamic, register)
+		}
+	})
+
+	// Export this package's registrations before merging in the imported ones
+	if len(registered) > 0 || dynamic {
+		fact := &registrations{Dynamic: dynamic}
+		for key := range registered {
+			fact.Types = append(fact.Types, key)
+		}
+		sort.Strings(fact.Types)
+		pass.ExportPackageFact(fact)
+	}
+
+	for _, pkgFact := range pass.AllPackageFacts() {
+		imported := pkgFact.Fact.(*registrations)
+		for _, key := range imported.Types {
+			registered[key] = true
+		}
+		dynamic = dynamic || imported.Dynamic
+	}
+
+	if !dynamic {
+		for _, c := range copies {
+			if !registered[typeKey(c.named)] {
+				name := c.named.Obj().Name()
+				pass.ReportRangef(c.call, "%s[%s] called on a type that is never registered; call cgocopy.Precompile[%s]() first",
+					c.name, name, name)
+			}
+		}
+	}
+
+	cStructs, err := loadHeaders(pass)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check in source order so that diagnostics are deterministic
+	var structs []*types.Named
+	for named := range checked {
+		if named.Obj().Pkg() == pass.Pkg {
+			structs = append(structs, named)
+		}
+	}
+	sort.Slice(structs, func(i, j int) bool {
+		return structs[i].Obj().Pos() < structs[j].Obj().Pos()
+	})
+	for _, named := range structs {
+		checkStruct(pass, named, cStructs)
+	}
+
+	return nil, nil
+}
+
+// registerImported registers the types passed to Import. Types passed as a
+// spread slice cannot be determined.
+func registerImported(info *types.Info, call *ast.CallExpr, dynamic *bool, register func(types.Type)) {
+	if call.Ellipsis != token.NoPos {
+		*dynamic = true
+		return
+	}
+	for _, arg := range call.Args[1:] {
+		register(reflectedType(info, arg))
+	}
+}
+
+// typeArg returns the first type argument of a call of a generic function.
+func typeArg(info *types.Info, call *ast.CallExpr) types.Type {
+	fun := ast.Unparen(call.Fun)
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+
+	var id *ast.Ident
+	switch f := fun.(type) {
+	case *ast.Ident:
+		id = f
+	case *ast.SelectorExpr:
+		id = f.Sel
+	default:
+		return nil
+	}
+
+	inst, ok := info.Instances[id]
+	if !ok || inst.TypeArgs.Len() == 0 {
+		return nil
+	}
+	return inst.TypeArgs.At(0)
+}
+
+// reflectedType returns the type described by a reflect.Type expression:
+// reflect.TypeFor[T](), reflect.TypeOf(x) or reflect.TypeOf(x).Elem(). It
+// returns nil if the type cannot be determined.
+func reflectedType(info *types.Info, expr ast.Expr) types.Type {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+
+	// reflect.TypeOf(x).Elem()
+	if sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr); ok && sel.Sel.Name == "Elem" && len(call.Args) == 0 {
+		if ptr, ok := reflectedType(info, sel.X).(*types.Pointer); ok {
+			return ptr.Elem()
+		}
+		return nil
+	}
+
+	fn, _ := typeutil.Callee(info, call).(*types.Func)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != "reflect" {
+		return nil
+	}
+	switch fn.Name() {
+	case "TypeFor":
+		return typeArg(info, call)
+	case "TypeOf":
+		if len(call.Args) == 1 {
+			return info.TypeOf(call.Args[0])
+		}
+	}
+	return nil
+}
+
+// structType returns the named struct type t describes, dereferencing
+// pointers as cgocopy does, or nil.
+func structType(t types.Type) *types.Named {
+	if t == nil {
+		return nil
+	}
+	t = types.Unalias(t)
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = types.Unalias(ptr.Elem())
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return nil
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil
+	}
+	return named
+}
+
+// typeKey returns the package-qualified name of a named type, as in the
+// registrations fact.
+func typeKey(named *types.Named) string {
+	obj := named.Origin().Obj()
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
+
+// recvName returns the name of a method's receiver type.
+func recvName(fn *types.Func) string {
+	t := fn.Signature().Recv().Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return ""
+}
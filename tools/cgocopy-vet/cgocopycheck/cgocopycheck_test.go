@@ -0,0 +1,22 @@
+package cgocopycheck
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "copies", "registered", "importer", "dynamic", "tags")
+}
+
+func TestAnalyzer_Headers(t *testing.T) {
+	if err := Analyzer.Flags.Set("headers", "structs.h"); err != nil {
+		t.Fatal(err)
+	}
+	defer Analyzer.Flags.Set("headers", "")
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "headers")
+}
@@ -0,0 +1,384 @@
+package cgocopycheck
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/shaban/cgocopy/tools/cgocopy-generate/cparse"
+	"golang.org/x/tools/go/analysis"
+)
+
+// cIdentifier matches a C identifier.
+var cIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// cgocopyTag is a parsed cgocopy struct tag, following the grammar of the
+// cgocopy package: `cgocopy:"c_name,flag,key=value"`, or `cgocopy:"-"`.
+type cgocopyTag struct {
+	present bool
+	name    string
+	skip    bool
+	options []tagOption
+}
+
+// tagOption is one comma-separated option of a cgocopy tag.
+type tagOption struct {
+	key, value string
+	hasValue   bool
+}
+
+// option returns the option called key.
+func (t cgocopyTag) option(key string) (tagOption, bool) {
+	for _, opt := range t.options {
+		if opt.key == key {
+			return opt, true
+		}
+	}
+	return tagOption{}, false
+}
+
+// parseTag parses the cgocopy key of a struct tag the way the cgocopy
+// package does.
+func parseTag(structTag string) cgocopyTag {
+	raw, ok := reflect.StructTag(structTag).Lookup("cgocopy")
+	if !ok {
+		return cgocopyTag{}
+	}
+
+	parts := strings.Split(raw, ",")
+	tag := cgocopyTag{present: true, name: strings.TrimSpace(parts[0])}
+	if tag.name == "-" {
+		tag.name, tag.skip = "", true
+	}
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, hasValue := strings.Cut(part, "=")
+		tag.options = append(tag.options, tagOption{
+			key:      strings.TrimSpace(key),
+			value:    strings.TrimSpace(value),
+			hasValue: hasValue,
+		})
+	}
+	return tag
+}
+
+// hasCgocopyTag reports whether a named struct type has a field with a
+// cgocopy tag.
+func hasCgocopyTag(named *types.Named) bool {
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if parseTag(st.Tag(i)).present {
+			return true
+		}
+	}
+	return false
+}
+
+// isFlattened reports whether the cgocopy package flattens a struct field
+// into its parent: embedded structs without a C name, and struct fields
+// with the flatten option.
+func isFlattened(field *types.Var, tag cgocopyTag) bool {
+	if _, ok := field.Type().Underlying().(*types.Struct); !ok || lazyElem(field.Type()) != nil {
+		return false
+	}
+	if _, ok := tag.option("flatten"); ok {
+		return true
+	}
+	return field.Embedded() && tag.name == ""
+}
+
+// walkNested calls visit for named and every struct type the cgocopy
+// package registers along with it: nested structs, array, slice, pointer
+// and Lazy targets. visit returns false to skip the types nested in a type.
+func walkNested(named *types.Named, visit func(*types.Named) bool) {
+	if !visit(named) {
+		return
+	}
+	walkFields(named.Underlying().(*types.Struct), visit)
+}
+
+func walkFields(st *types.Struct, visit func(*types.Named) bool) {
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := parseTag(st.Tag(i))
+		if tag.skip {
+			continue
+		}
+		if isFlattened(field, tag) {
+			walkFields(field.Type().Underlying().(*types.Struct), visit)
+			continue
+		}
+		if field.Exported() {
+			walkType(field.Type(), visit)
+		}
+	}
+}
+
+func walkType(t types.Type, visit func(*types.Named) bool) {
+	switch t := types.Unalias(t).(type) {
+	case *types.Pointer:
+		walkType(t.Elem(), visit)
+	case *types.Slice:
+		walkType(t.Elem(), visit)
+	case *types.Array:
+		walkType(t.Elem(), visit)
+	case *types.Named:
+		if elem := lazyElem(t); elem != nil {
+			walkType(elem, visit)
+			return
+		}
+		if _, ok := t.Underlying().(*types.Struct); ok {
+			walkNested(t, visit)
+		}
+	}
+}
+
+// lazyElem returns T for the cgocopy Lazy[T] type, or nil.
+func lazyElem(t types.Type) types.Type {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.TypeArgs().Len() != 1 {
+		return nil
+	}
+	obj := named.Obj()
+	if obj.Pkg() == nil || obj.Pkg().Path() != cgocopyPath || obj.Name() != "Lazy" {
+		return nil
+	}
+	return named.TypeArgs().At(0)
+}
+
+// loadHeaders parses the C headers named by the -headers flag, keyed by C
+// struct name. It returns nil without the flag.
+func loadHeaders(pass *analysis.Pass) (map[string]cparse.Struct, error) {
+	if headers == "" || len(pass.Files) == 0 {
+		return nil, nil
+	}
+
+	dir := filepath.Dir(pass.Fset.File(pass.Files[0].Pos()).Name())
+	cStructs := make(map[string]cparse.Struct)
+	for _, path := range strings.Split(headers, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading C header: %w", err)
+		}
+		structs, err := cparse.ParseStructs(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("parsing C header %s: %w", path, err)
+		}
+		for _, s := range structs {
+			cStructs[s.Name] = s
+		}
+	}
+	return cStructs, nil
+}
+
+// checkStruct reports malformed tags and unsupported field kinds of a
+// struct type, and, when its C struct is among cStructs, tags naming C
+// fields the C struct does not have.
+func checkStruct(pass *analysis.Pass, named *types.Named, cStructs map[string]cparse.Struct) {
+	st := named.Underlying().(*types.Struct)
+
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := parseTag(st.Tag(i))
+
+		if field.Name() == "_" {
+			if tag.name != "" && !cIdentifier.MatchString(tag.name) {
+				pass.Reportf(field.Pos(), "cgocopy tag %q is not a valid C type name", tag.name)
+			}
+			continue
+		}
+		if tag.skip {
+			if len(tag.options) > 0 {
+				pass.Reportf(field.Pos(), "cgocopy tag options on skipped field %s have no effect", field.Name())
+			}
+			continue
+		}
+
+		checkTag(pass, field, tag)
+
+		if isFlattened(field, tag) || !field.Exported() {
+			continue
+		}
+		if kind := unsupportedKind(field.Type()); kind != "" {
+			pass.Reportf(field.Pos(), "field %s has kind %s, which cgocopy cannot copy; skip it with `cgocopy:\"-\"`",
+				field.Name(), kind)
+		}
+		if _, isMap := field.Type().Underlying().(*types.Map); isMap {
+			if _, ok := tag.option("len"); !ok {
+				pass.Reportf(field.Pos(), "map field %s needs a len=<c_field> cgocopy tag option", field.Name())
+			}
+		}
+	}
+
+	// The C struct name is set on a blank field, as for PrecompileAuto, and
+	// defaults to the Go type name
+	cName := named.Obj().Name()
+	for i := 0; i < st.NumFields(); i++ {
+		if tag := parseTag(st.Tag(i)); st.Field(i).Name() == "_" && tag.name != "" {
+			cName = tag.name
+		}
+	}
+	if cStruct, ok := cStructs[cName]; ok {
+		cFields := make(map[string]bool, len(cStruct.Fields))
+		for _, f := range cStruct.Fields {
+			cFields[f.Name] = true
+		}
+		checkCFieldNames(pass, st, cName, cFields)
+	}
+}
+
+// checkCFieldNames reports tags of st naming C fields that the C struct
+// cName does not have. Fields of flattened structs declared in the package
+// are checked against the same C struct.
+func checkCFieldNames(pass *analysis.Pass, st *types.Struct, cName string, cFields map[string]bool) {
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := parseTag(st.Tag(i))
+		if field.Name() == "_" || tag.skip {
+			continue
+		}
+
+		if tag.name != "" && !cFields[tag.name] {
+			pass.Reportf(field.Pos(), "cgocopy tag on %s names C field %s, which C struct %s does not have",
+				field.Name(), tag.name, cName)
+		}
+		for _, key := range []string{"len", "values"} {
+			if opt, ok := tag.option(key); ok && opt.value != "" && !cFields[opt.value] {
+				pass.Reportf(field.Pos(), "cgocopy tag option %s=%s on %s names C field %s, which C struct %s does not have",
+					key, opt.value, field.Name(), opt.value, cName)
+			}
+		}
+
+		if isFlattened(field, tag) {
+			if named, ok := types.Unalias(field.Type()).(*types.Named); ok && named.Obj().Pkg() == pass.Pkg {
+				checkCFieldNames(pass, named.Underlying().(*types.Struct), cName, cFields)
+			}
+		}
+	}
+}
+
+// checkTag reports malformed cgocopy tags: an invalid C name, unknown or
+// repeated options, and options that do not apply to the field type.
+func checkTag(pass *analysis.Pass, field *types.Var, tag cgocopyTag) {
+	if !tag.present {
+		return
+	}
+	pos := field.Pos()
+
+	if tag.name != "" && !cIdentifier.MatchString(tag.name) {
+		pass.Reportf(pos, "cgocopy tag on %s: %q is not a valid C field name", field.Name(), tag.name)
+	}
+
+	seen := make(map[string]bool)
+	for _, opt := range tag.options {
+		if seen[opt.key] {
+			pass.Reportf(pos, "cgocopy tag on %s repeats option %s", field.Name(), opt.key)
+		}
+		seen[opt.key] = true
+
+		switch opt.key {
+		case "required", "flatten":
+			if opt.hasValue {
+				pass.Reportf(pos, "cgocopy tag option %s on %s takes no value", opt.key, field.Name())
+			}
+		case "default":
+		case "len", "values":
+			if !cIdentifier.MatchString(opt.value) {
+				pass.Reportf(pos, "cgocopy tag option %s on %s needs a C field name (%s=<c_field>)",
+					opt.key, field.Name(), opt.key)
+			}
+		case "dup":
+			if opt.value != "error" && opt.value != "last" {
+				pass.Reportf(pos, "cgocopy tag option dup=%s on %s: want dup=error or dup=last", opt.value, field.Name())
+			}
+		default:
+			pass.Reportf(pos, "cgocopy tag on %s has unknown option %q", field.Name(), opt.key)
+		}
+	}
+
+	// Options that apply to some field types only
+	t := types.Unalias(field.Type())
+	_, isMap := t.Underlying().(*types.Map)
+	_, isStruct := t.Underlying().(*types.Struct)
+	_, isPointer := t.Underlying().(*types.Pointer)
+	isString := false
+	if basic, ok := t.Underlying().(*types.Basic); ok {
+		isString = basic.Info()&types.IsString != 0
+	}
+
+	_, required := tag.option("required")
+	_, hasDefault := tag.option("default")
+	if (required || hasDefault) && !isString && !isPointer {
+		pass.Reportf(pos, "cgocopy tag options required and default on %s apply only to string and pointer fields", field.Name())
+	}
+	if required && hasDefault {
+		pass.Reportf(pos, "cgocopy tag options required and default on %s are mutually exclusive", field.Name())
+	}
+	if _, ok := tag.option("flatten"); ok && (!isStruct || lazyElem(t) != nil) {
+		pass.Reportf(pos, "cgocopy tag option flatten on %s applies only to struct fields", field.Name())
+	}
+	for _, key := range []string{"len", "values", "dup"} {
+		if _, ok := tag.option(key); ok && !isMap {
+			pass.Reportf(pos, "cgocopy tag option %s on %s applies only to map fields", key, field.Name())
+		}
+	}
+}
+
+// unsupportedKind returns the kind of t if the cgocopy package cannot copy
+// it, looking through arrays, slices and pointers, or "".
+func unsupportedKind(t types.Type) string {
+	switch u := types.Unalias(t).Underlying().(type) {
+	case *types.Chan:
+		return "chan"
+	case *types.Signature:
+		return "func"
+	case *types.Interface:
+		return "interface"
+	case *types.Basic:
+		if u.Info()&types.IsComplex != 0 {
+			return u.Name()
+		}
+	case *types.Array:
+		return unsupportedKind(u.Elem())
+	case *types.Slice:
+		return unsupportedKind(u.Elem())
+	case *types.Pointer:
+		return unsupportedKind(u.Elem())
+	case *types.Map:
+		for _, kt := range []types.Type{u.Key(), u.Elem()} {
+			if !isMapElem(kt) {
+				return "map[" + types.TypeString(u.Key(), nil) + "]" + types.TypeString(u.Elem(), nil)
+			}
+		}
+	}
+	return ""
+}
+
+// isMapElem reports whether t can be a map key or value: a primitive or a
+// string, as the cgocopy package requires.
+func isMapElem(t types.Type) bool {
+	basic, ok := types.Unalias(t).Underlying().(*types.Basic)
+	if !ok {
+		return false
+	}
+	return basic.Info()&(types.IsInteger|types.IsFloat|types.IsBoolean|types.IsString) != 0 &&
+		basic.Kind() != types.Uintptr
+}
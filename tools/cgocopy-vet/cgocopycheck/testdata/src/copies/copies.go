@@ -0,0 +1,52 @@
+package copies // want package:`registrations\(copies.Inner, copies.Registered, copies.ViaRegistry, copies.ViaTypeOf\)`
+
+import (
+	"reflect"
+	"unsafe"
+
+	cgocopy "github.com/shaban/cgocopy/pkg/cgocopy"
+)
+
+type Registered struct {
+	ID    int32
+	Inner Inner
+}
+
+type Inner struct {
+	X int32
+}
+
+type ViaRegistry struct {
+	X int32
+}
+
+type ViaTypeOf struct {
+	X int32
+}
+
+type Unregistered struct {
+	X int32
+}
+
+func init() {
+	cgocopy.Precompile[Registered]()
+
+	reg := cgocopy.NewRegistry()
+	reg.Precompile(reflect.TypeFor[ViaRegistry]())
+	reg.Precompile(reflect.TypeOf((*ViaTypeOf)(nil)).Elem())
+}
+
+func copies(p unsafe.Pointer) {
+	cgocopy.Copy[Registered](p)
+	cgocopy.Copy[*Registered](p)
+	cgocopy.Copy[Inner](p)
+	cgocopy.CopyWith[ViaRegistry](nil, p)
+	cgocopy.Copy[ViaTypeOf](p)
+	cgocopy.Copy[Unregistered](p) // want `Copy\[Unregistered\] called on a type that is never registered; call cgocopy.Precompile\[Unregistered\]\(\) first`
+	cgocopy.Copy[int32](p)
+}
+
+// Calls in generic code are not checked
+func copyAny[T any](p unsafe.Pointer) (T, error) {
+	return cgocopy.Copy[T](p)
+}
@@ -0,0 +1,23 @@
+package dynamic // want package:`registrations\(dynamic\)`
+
+import (
+	"reflect"
+	"unsafe"
+
+	cgocopy "github.com/shaban/cgocopy/pkg/cgocopy"
+)
+
+type Unknown struct {
+	X int32
+}
+
+func register(reg *cgocopy.Registry, types ...reflect.Type) {
+	for _, t := range types {
+		reg.Precompile(t)
+	}
+}
+
+// Registrations of unknown types disable the check
+func copies(p unsafe.Pointer) {
+	cgocopy.Copy[Unknown](p)
+}
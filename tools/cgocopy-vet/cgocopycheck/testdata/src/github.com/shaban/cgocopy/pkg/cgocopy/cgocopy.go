@@ -0,0 +1,34 @@
+// Package cgocopy2 is a stub of the cgocopy API used by the analyzer tests.
+package cgocopy2
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+type Registry struct{}
+
+type CStructInfo struct{}
+
+type Lazy[T any] struct{ ptr unsafe.Pointer }
+
+func Precompile[T any]() error                       { return nil }
+func PrecompileWithC[T any](cInfo CStructInfo) error { return nil }
+func PrecompileAuto[T any]() error                   { return nil }
+func Import(data []byte, types ...reflect.Type) error {
+	return nil
+}
+
+func Copy[T any](cPtr unsafe.Pointer) (T, error) {
+	var zero T
+	return zero, nil
+}
+
+func CopyWith[T any](reg *Registry, cPtr unsafe.Pointer) (T, error) {
+	var zero T
+	return zero, nil
+}
+
+func NewRegistry() *Registry { return &Registry{} }
+
+func (r *Registry) Precompile(goType reflect.Type) error { return nil }
@@ -0,0 +1,24 @@
+package headers
+
+type Base struct {
+	Kind int32 `cgocopy:"knd"` // want `cgocopy tag on Kind names C field knd, which C struct device_t does not have`
+}
+
+type Device struct {
+	_    struct{}        `cgocopy:"device_t"`
+	Base                 // flattened: checked against device_t
+	ID   int32           `cgocopy:"id"`
+	Name string          `cgocopy:"nmae"`                         // want `cgocopy tag on Name names C field nmae, which C struct device_t does not have`
+	Tags map[int32]int32 `cgocopy:"keys,values=values,len=count"` // want `cgocopy tag option values=values on Tags names C field values, which C struct device_t does not have`
+}
+
+// The C struct name defaults to the Go type name
+type Point struct {
+	X int32 `cgocopy:"x"`
+	Z int32 `cgocopy:"z"` // want `cgocopy tag on Z names C field z, which C struct Point does not have`
+}
+
+// Types without a C struct in the headers are not checked
+type Unknown struct {
+	A int32 `cgocopy:"anything"`
+}
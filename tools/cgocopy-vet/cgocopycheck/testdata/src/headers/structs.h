@@ -0,0 +1,17 @@
+#include <stddef.h>
+#include <stdint.h>
+
+// A device as reported by the driver
+typedef struct {
+    int32_t kind;
+    int32_t id;
+    char* name;
+    int32_t* keys;
+    int32_t* vals;
+    size_t count;
+} device_t;
+
+struct Point {
+    int x;
+    int y;
+};
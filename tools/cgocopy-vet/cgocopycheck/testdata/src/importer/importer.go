@@ -0,0 +1,14 @@
+package importer
+
+import (
+	"unsafe"
+
+	cgocopy "github.com/shaban/cgocopy/pkg/cgocopy"
+
+	"registered"
+)
+
+func copies(p unsafe.Pointer) {
+	cgocopy.Copy[registered.Device](p)
+	cgocopy.Copy[registered.Other](p) // want `Copy\[Other\] called on a type that is never registered`
+}
@@ -0,0 +1,15 @@
+package registered // want package:`registrations\(registered.Device\)`
+
+import cgocopy "github.com/shaban/cgocopy/pkg/cgocopy"
+
+type Device struct {
+	ID int32
+}
+
+type Other struct {
+	ID int32
+}
+
+func init() {
+	cgocopy.Precompile[Device]()
+}
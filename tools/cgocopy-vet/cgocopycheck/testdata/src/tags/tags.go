@@ -0,0 +1,60 @@
+package tags // want package:`registrations\(tags.Kinds\)`
+
+import (
+	"unsafe"
+
+	cgocopy "github.com/shaban/cgocopy/pkg/cgocopy"
+)
+
+type Header struct {
+	Version int32 `cgocopy:"version"`
+}
+
+type Valid struct {
+	_       struct{}          `cgocopy:"valid_t"`
+	Header                    // flattened
+	ID      int32             `cgocopy:"id"`
+	Name    string            `cgocopy:"name,required"`
+	Label   *string           `cgocopy:"label,default=none"`
+	Tags    map[string]int32  `cgocopy:"keys,values=vals,len=count,dup=last"`
+	Pairs   map[int32]float64 `cgocopy:"pairs,len=n"`
+	Handle  unsafe.Pointer    `cgocopy:"handle"`
+	Next    cgocopy.Lazy[Valid]
+	Cache   chan int `cgocopy:"-"`
+	private func()
+}
+
+type Malformed struct {
+	_       struct{}         `cgocopy:"bad name"`                 // want `cgocopy tag "bad name" is not a valid C type name`
+	ID      int32            `cgocopy:"id-x"`                     // want `cgocopy tag on ID: "id-x" is not a valid C field name`
+	Count   int32            `cgocopy:"count,reqired"`            // want `cgocopy tag on Count has unknown option "reqired"`
+	Name    string           `cgocopy:"name,required=yes"`        // want `cgocopy tag option required on Name takes no value`
+	Size    int32            `cgocopy:"size,required"`            // want `cgocopy tag options required and default on Size apply only to string and pointer fields`
+	Title   string           `cgocopy:"title,required,default=x"` // want `cgocopy tag options required and default on Title are mutually exclusive`
+	Flag    bool             `cgocopy:"flag,len=n"`               // want `cgocopy tag option len on Flag applies only to map fields`
+	Inner   Header           `cgocopy:"inner,flatten,flatten"`    // want `cgocopy tag on Inner repeats option flatten`
+	Flat    int32            `cgocopy:"flat,flatten"`             // want `cgocopy tag option flatten on Flat applies only to struct fields`
+	Map     map[string]int32 `cgocopy:"map"`                      // want `map field Map needs a len=<c_field> cgocopy tag option`
+	Dup     map[string]int32 `cgocopy:"dup,len=n,dup=first"`      // want `cgocopy tag option dup=first on Dup: want dup=error or dup=last`
+	Values  map[string]int32 `cgocopy:"values,len=,values=v"`     // want `cgocopy tag option len on Values needs a C field name \(len=<c_field>\)`
+	Skipped int32            `cgocopy:"-,required"`               // want `cgocopy tag options on skipped field Skipped have no effect`
+}
+
+// Untagged types are checked once they are copied or registered
+type Kinds struct {
+	Events  chan int           // want `field Events has kind chan, which cgocopy cannot copy; skip it with .cgocopy:"-".`
+	Handler func()             // want `field Handler has kind func, which cgocopy cannot copy`
+	Value   any                // want `field Value has kind interface, which cgocopy cannot copy`
+	Phase   complex128         // want `field Phase has kind complex128, which cgocopy cannot copy`
+	Queues  [2]chan int        // want `field Queues has kind chan, which cgocopy cannot copy`
+	Nested  map[string][]int32 `cgocopy:"nested,len=n"` // want `field Nested has kind map\[string\]\[\]int32, which cgocopy cannot copy`
+}
+
+type Unchecked struct {
+	Events chan int
+}
+
+func copies(p unsafe.Pointer) {
+	cgocopy.Precompile[Kinds]()
+	cgocopy.Copy[Kinds](p)
+}
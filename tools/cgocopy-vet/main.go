@@ -0,0 +1,18 @@
+// Command cgocopy-vet reports misuse of the cgocopy package: copies of
+// unregistered types, malformed cgocopy tags, fields cgocopy cannot copy and
+// tags naming missing C fields. See package cgocopycheck for details.
+//
+// Run it standalone or through go vet:
+//
+//	cgocopy-vet ./...
+//	go vet -vettool=$(which cgocopy-vet) ./...
+package main
+
+import (
+	"github.com/shaban/cgocopy/tools/cgocopy-vet/cgocopycheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(cgocopycheck.Analyzer)
+}